@@ -0,0 +1,56 @@
+package surf
+
+import (
+	"net/url"
+)
+
+// TypedBody is a request body encoded with its own content type, built by JSON, XML or Form.
+// Its content type is sent unless the request already sets a Content-Type header.
+type TypedBody struct {
+	contentType string
+	value       interface{}
+	encode      func(rc *RequestConfig) ([]byte, error)
+}
+
+// JSON returns a request body encoded with the request JSONMarshal, sent as application/json.
+func JSON(v interface{}) *TypedBody {
+	return &TypedBody{
+		contentType: defaultJsonContentType,
+		value:       v,
+		encode: func(rc *RequestConfig) ([]byte, error) {
+			return rc.JSONMarshal(v)
+		},
+	}
+}
+
+// XML returns a request body encoded with the request XMLMarshal, sent as application/xml.
+func XML(v interface{}) *TypedBody {
+	return &TypedBody{
+		contentType: defaultXmlContentType,
+		value:       v,
+		encode: func(rc *RequestConfig) ([]byte, error) {
+			return rc.XMLMarshal(v)
+		},
+	}
+}
+
+// Form returns a request body URL-encoded from values, sent as application/x-www-form-urlencoded.
+func Form(values url.Values) *TypedBody {
+	return &TypedBody{
+		contentType: defaultFormContentType,
+		value:       values,
+		encode: func(rc *RequestConfig) ([]byte, error) {
+			return []byte(values.Encode()), nil
+		},
+	}
+}
+
+// ContentType returns the content type of the body.
+func (b *TypedBody) ContentType() string {
+	return b.contentType
+}
+
+// Value returns the value encoded as the body.
+func (b *TypedBody) Value() interface{} {
+	return b.value
+}
@@ -0,0 +1,51 @@
+package surf
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestTypedBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	type item struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	data := []struct {
+		name        string
+		body        *TypedBody
+		args        []WithRequestConfig
+		expect      string
+		contentType string
+	}{
+		{name: "json", body: JSON(map[string]interface{}{"name": "surf"}), expect: `{"name":"surf"}`, contentType: defaultJsonContentType},
+		{name: "xml", body: XML(item{Name: "surf"}), expect: "<item><name>surf</name></item>", contentType: defaultXmlContentType},
+		{name: "form", body: Form(url.Values{"name": {"surf"}}), expect: "name=surf", contentType: defaultFormContentType},
+		{
+			name:        "content type set",
+			body:        JSON(item{Name: "surf"}),
+			args:        []WithRequestConfig{WithSetHeader(http.Header{"Content-Type": {"application/vnd.api+json"}})},
+			expect:      `{"name":"surf"}`,
+			contentType: "application/vnd.api+json",
+		},
+	}
+
+	for _, item := range data {
+		resp, err := New(nil).Post(server.URL, append([]WithRequestConfig{WithBody(item.body)}, item.args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != item.expect || resp.Headers().Get("X-Content-Type") != item.contentType {
+			t.Fatalf("%s: expect %s %s output %s %s.", item.name, item.contentType, item.expect, resp.Headers().Get("X-Content-Type"), resp.Text())
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package surf
+
+// Browser identifies a browser header preset for WithBrowserHeaders.
+type Browser string
+
+const (
+	BrowserChrome  Browser = "chrome"
+	BrowserFirefox Browser = "firefox"
+	BrowserSafari  Browser = "safari"
+)
+
+// browserHeader is a single header of a browser preset.
+type browserHeader struct {
+	key   string
+	value string
+}
+
+// browserPresets are the navigation request headers sent by desktop browsers, in the order they send them.
+// Accept-Encoding is left to surf so that only encodings it can decode are advertised.
+var browserPresets = map[Browser][]browserHeader{
+	BrowserChrome: {
+		{"Sec-Ch-Ua", `"Google Chrome";v="141", "Not?A_Brand";v="8", "Chromium";v="141"`},
+		{"Sec-Ch-Ua-Mobile", "?0"},
+		{"Sec-Ch-Ua-Platform", `"Windows"`},
+		{"Upgrade-Insecure-Requests", "1"},
+		{"User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36"},
+		{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8"},
+		{"Sec-Fetch-Site", "none"},
+		{"Sec-Fetch-Mode", "navigate"},
+		{"Sec-Fetch-User", "?1"},
+		{"Sec-Fetch-Dest", "document"},
+		{"Accept-Encoding", ""},
+		{"Accept-Language", "en-US,en;q=0.9"},
+	},
+	BrowserFirefox: {
+		{"User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:143.0) Gecko/20100101 Firefox/143.0"},
+		{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+		{"Accept-Language", "en-US,en;q=0.5"},
+		{"Accept-Encoding", ""},
+		{"Upgrade-Insecure-Requests", "1"},
+		{"Sec-Fetch-Dest", "document"},
+		{"Sec-Fetch-Mode", "navigate"},
+		{"Sec-Fetch-Site", "none"},
+		{"Sec-Fetch-User", "?1"},
+	},
+	BrowserSafari: {
+		{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+		{"Sec-Fetch-Site", "none"},
+		{"Accept-Encoding", ""},
+		{"Sec-Fetch-Mode", "navigate"},
+		{"User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Safari/605.1.15"},
+		{"Accept-Language", "en-US,en;q=0.9"},
+		{"Sec-Fetch-Dest", "document"},
+	},
+}
+
+// WithBrowserHeaders applies the navigation headers of the given browser (Chrome if unknown)
+// in the request configuration, and their order unless WithHeaderOrder was already used.
+// Options applied afterwards override individual headers.
+func WithBrowserHeaders(browser Browser) WithRequestConfig {
+	return func(c *RequestConfig) {
+		preset, ok := browserPresets[browser]
+		if !ok {
+			preset = browserPresets[BrowserChrome]
+		}
+
+		order := make([]string, 0, len(preset))
+		for _, h := range preset {
+			order = append(order, h.key)
+			if h.value != "" {
+				c.SetHeader(h.key, h.value)
+			}
+		}
+		if len(c.HeaderOrder) == 0 {
+			c.HeaderOrder = order
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package surf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWithBrowserHeaders(t *testing.T) {
+	config := combineRequestConfig(WithBrowserHeaders(BrowserFirefox))
+
+	var order []string
+	for _, h := range browserPresets[BrowserFirefox] {
+		order = append(order, h.key)
+		if h.value != "" && config.Header.Get(h.key) != h.value {
+			t.Fatalf("header %s expect %s output %s.", h.key, h.value, config.Header.Get(h.key))
+		}
+	}
+	if !reflect.DeepEqual(config.HeaderOrder, order) {
+		t.Fatalf("header order expect %v output %v.", order, config.HeaderOrder)
+	}
+	if config.Header.Get(headerAcceptEncoding) != "" {
+		t.Fatalf("accept encoding expect left to surf output %s.", config.Header.Get(headerAcceptEncoding))
+	}
+
+	config = combineRequestConfig(WithHeaderOrder([]string{"Accept", "User-Agent"}), WithBrowserHeaders("unknown"))
+	if config.Header.Get("Sec-Ch-Ua-Mobile") != "?0" {
+		t.Fatal("unknown browser expect chrome preset.")
+	}
+	if !reflect.DeepEqual(config.HeaderOrder, []string{"Accept", "User-Agent"}) {
+		t.Fatalf("header order expect kept output %v.", config.HeaderOrder)
+	}
+}
+
+func TestWithBrowserHeaders_Override(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Sec-Fetch-Mode") + "|" + r.UserAgent()))
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL,
+		WithBrowserHeaders(BrowserSafari),
+		WithSetHeader(http.Header{"User-Agent": {"surf"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "navigate|surf" {
+		t.Fatalf("browser headers expect navigate|surf output %s.", resp.Text())
+	}
+}
@@ -0,0 +1,76 @@
+package surf
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// checksumHashes are the supported checksum algorithms.
+var checksumHashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// checksum returns the hex encoded checksum of data, false if the algorithm is not supported.
+func checksum(algo string, data []byte) (string, bool) {
+	h, ok := newChecksumHash(algo)
+	if !ok {
+		return "", false
+	}
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// newChecksumHash returns a hash of the given algorithm, false if it is not supported.
+func newChecksumHash(algo string) (hash.Hash, bool) {
+	newHash, ok := checksumHashes[strings.ToLower(algo)]
+	if !ok {
+		return nil, false
+	}
+	return newHash(), true
+}
+
+// checksumMismatch returns the error of a checksum differing from the expected one.
+func checksumMismatch(algo, expected, sum string) error {
+	if strings.EqualFold(sum, expected) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s expected %s, got %s", ErrChecksumMismatch, algo, expected, sum)
+}
+
+// checksumBody verifies the checksum of an unbuffered response body while it is read,
+// the Read reaching the end of the body returns the mismatch error instead of io.EOF.
+type checksumBody struct {
+	io.ReadCloser
+	hash     hash.Hash
+	algo     string
+	expected string
+}
+
+// Read implements io.Reader.
+func (b *checksumBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	if err == io.EOF {
+		if mismatch := checksumMismatch(b.algo, b.expected, hex.EncodeToString(b.hash.Sum(nil))); mismatch != nil {
+			return n, mismatch
+		}
+	}
+	return n, err
+}
+
+// Checksum returns the hex encoded checksum of the response body with the given algorithm
+// (md5, sha1, sha256 or sha512), empty if the algorithm is not supported.
+func (r *Response) Checksum(algo string) string {
+	sum, _ := checksum(algo, r.body)
+	return sum
+}
@@ -0,0 +1,62 @@
+package surf
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithExpectedChecksum(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("surf"))
+	}))
+	defer server.Close()
+
+	const sum = "0199311728be829918c18b5f1486d315ba690ed58b69b4c8236a16416130bcd2"
+
+	resp, err := New(nil).Get(server.URL, WithExpectedChecksum("sha256", sum))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Checksum("sha256") != sum {
+		t.Fatalf("checksum expect %s output %s.", sum, resp.Checksum("sha256"))
+	}
+
+	_, err = New(nil).Get(server.URL, WithExpectedChecksum("md5", sum))
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expect checksum mismatch output %v.", err)
+	}
+}
+
+func TestWithExpectedChecksum_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("surf"))
+	}))
+	defer server.Close()
+
+	const sum = "0199311728be829918c18b5f1486d315ba690ed58b69b4c8236a16416130bcd2"
+
+	for _, opt := range []WithRequestConfig{WithStream(), WithResponseBodyConsumed(false)} {
+		resp, err := New(nil).Get(server.URL, opt, WithExpectedChecksum("sha256", sum))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(resp.BodyReadCloser())
+		resp.Close()
+		if err != nil || string(data) != "surf" {
+			t.Fatalf("checksum body expect surf output %s %v.", data, err)
+		}
+
+		resp, err = New(nil).Get(server.URL, opt, WithExpectedChecksum("md5", sum))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = io.ReadAll(resp.BodyReadCloser())
+		resp.Close()
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("expect checksum mismatch at end of body output %v.", err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package surf
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"sync"
+)
+
+// Package-level codecs used when neither the request nor the Config sets one.
+var (
+	codecMu              sync.RWMutex
+	defaultJSONMarshal   = json.Marshal
+	defaultJSONUnmarshal = json.Unmarshal
+	defaultXMLMarshal    = xml.Marshal
+	defaultXMLUnmarshal  = xml.Unmarshal
+)
+
+// SetDefaultJSONCodec replaces the JSON codec used by every client whose Config does not set one,
+// including DefaultConfig, for example to switch to a faster JSON library globally.
+// Passing nil restores the encoding/json function.
+func SetDefaultJSONCodec(marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+
+	defaultJSONMarshal = defaultValue(marshal, json.Marshal)
+	defaultJSONUnmarshal = defaultValue(unmarshal, json.Unmarshal)
+}
+
+// SetDefaultXMLCodec replaces the XML codec used by every client whose Config does not set one,
+// including DefaultConfig. Passing nil restores the encoding/xml function.
+func SetDefaultXMLCodec(marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+
+	defaultXMLMarshal = defaultValue(marshal, xml.Marshal)
+	defaultXMLUnmarshal = defaultValue(unmarshal, xml.Unmarshal)
+}
+
+// defaultJSONCodec returns the package-level JSON codec.
+func defaultJSONCodec() (func(v interface{}) ([]byte, error), func(data []byte, v interface{}) error) {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+
+	return defaultJSONMarshal, defaultJSONUnmarshal
+}
+
+// defaultXMLCodec returns the package-level XML codec.
+func defaultXMLCodec() (func(v interface{}) ([]byte, error), func(data []byte, v interface{}) error) {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+
+	return defaultXMLMarshal, defaultXMLUnmarshal
+}
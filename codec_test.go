@@ -0,0 +1,24 @@
+package surf
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSetDefaultJSONCodec(t *testing.T) {
+	called := false
+	SetDefaultJSONCodec(func(v interface{}) ([]byte, error) {
+		called = true
+		return json.Marshal(v)
+	}, nil)
+	defer SetDefaultJSONCodec(nil, nil)
+
+	config := &RequestConfig{}
+	config.mergeConfig(&Config{})
+	if _, err := config.JSONMarshal(1); err != nil || !called {
+		t.Fatalf("expect default json codec to be used, err %v.", err)
+	}
+	if config.JSONUnmarshal == nil {
+		t.Fatal("expect default json unmarshal")
+	}
+}
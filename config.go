@@ -4,10 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"encoding/xml"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -18,12 +19,31 @@ type (
 	RequestInterceptor func(config *RequestConfig) error
 	// ResponseInterceptor defines a function signature for response interceptors.
 	ResponseInterceptor func(resp *Response) error
+	// RequestModifier defines a function signature for modifying the raw http.Request before it is sent.
+	RequestModifier func(req *http.Request)
+	// ModifierRun selects when a RequestModifier runs, see WithRequestModifier.
+	ModifierRun int
+	// ResponseValidator defines a function signature for response validators,
+	// a non-nil error fails the request.
+	ResponseValidator func(resp *Response) error
+	// ResultValidator defines a function signature for validating the decoded Result,
+	// a non-nil error fails the request.
+	ResultValidator func(result interface{}) error
 
 	// RequestInterceptorChain alias for RequestInterceptors
 	RequestInterceptorChain []RequestInterceptor
 	// ResponseInterceptorChain alias for ResponseInterceptors
 	ResponseInterceptorChain []ResponseInterceptor
 
+	// BodyWriter streams the request body by writing to w while the request is being sent.
+	// An error returned from the writer aborts the request.
+	BodyWriter func(w io.Writer) error
+
+	// Logger receives the logs written by Surf, *log.Logger implements it.
+	Logger interface {
+		Printf(format string, v ...interface{})
+	}
+
 	// QuerySerializer is responsible for encoding URL query parameters.
 	QuerySerializer struct {
 		Encode func(values url.Values) string
@@ -31,12 +51,18 @@ type (
 
 	// Config holds the configuration for Surf.
 	Config struct {
-		BaseURL   string
+		BaseURL string
+		// BasePath is a path prefix joined between BaseURL and every relative request Url.
+		BasePath  string
 		Header    http.Header
 		Timeout   time.Duration
 		Cookies   []*http.Cookie
 		CookieJar *http.CookieJar
 
+		// AcceptLanguage are the language tags sent in the Accept-Language header, in order of
+		// preference, unless the request already sets the header, see WithAcceptLanguage.
+		AcceptLanguage []string
+
 		Params map[string]string
 		Query  url.Values
 
@@ -51,8 +77,89 @@ type (
 		MaxBodyLength int
 		MaxRedirects  int
 
+		// DefaultReadBufferSize is the initial capacity of the buffer reading a response body
+		// without Content-Length, 16KB if not set. Tune it to the typical size of chunked responses.
+		DefaultReadBufferSize int
+
+		// MaxConcurrentRequests limits the number of in-flight requests of a Surf instance,
+		// requests block until a slot is free or their context is done. Zero means no limit.
+		// It can be changed at any time, requests already holding a slot are not affected.
+		MaxConcurrentRequests int
+
+		// MaxConcurrentRequestsPerHost limits the number of in-flight requests per URL host
+		// of a Surf instance. This is an application-level limit: requests wait before being
+		// sent, regardless of how many connections the transport holds. Zero means no limit.
+		// It can be changed at any time, requests already holding a slot are not affected.
+		MaxConcurrentRequestsPerHost int
+
+		// MaxConnsPerHost limits the number of connections per host at the transport level,
+		// see http.Transport.MaxConnsPerHost. Requests beyond the limit are queued by the
+		// transport itself. It only applies when the client transport is an *http.Transport,
+		// and not to a Client supplied with a request.
+		MaxConnsPerHost int
+
+		// IdleConnTimeout closes idle pooled connections after the duration, see http.Transport.IdleConnTimeout.
+		// Set it below the idle timeout of a load balancer to avoid reusing connections it already dropped.
+		// Zero keeps the transport setting, 90 seconds for http.DefaultTransport.
+		IdleConnTimeout time.Duration
+
+		// KeepAlive is the interval of TCP keep-alive probes on new connections, see net.Dialer.KeepAlive.
+		// Zero keeps the transport dialer, whose default is 30 seconds, and a negative value disables them.
+		// Setting it replaces the dialer of the transport.
+		KeepAlive time.Duration
+
+		// OnResponseHeaders is called with the final response once its headers are received, before
+		// the body is read, e.g. to reject a body whose Content-Length is too large. A non-nil error
+		// closes the body unread and is returned from the request.
+		OnResponseHeaders func(resp *http.Response) error
+
+		// AutoReferer sends the URL of the last response received by the Surf instance as the Referer
+		// of its next request, after redirects, as a browser navigating between pages does. A Referer
+		// set on the request is kept and none is sent from an https page to an http URL.
+		// Concurrent requests share it: each sends the URL of the last response completed before
+		// it is prepared, so the chain only follows the navigation of sequential requests.
+		// See WithAutoReferer to enable it with options.
+		AutoReferer bool
+
+		// TrackInFlight registers the requests in progress, listed by Surf.InFlight to diagnose stalls.
+		// It is disabled by default to avoid the bookkeeping cost.
+		TrackInFlight bool
+
+		transportOnce sync.Once
+		transport     http.RoundTripper
+
+		// TransportFor selects the transport of the requests to a URL host, such as "api.example.com:8443",
+		// e.g. to use a distinct mTLS client certificate per service. It is called once per host, the
+		// transport is cached and reused so connections are pooled. It overrides the transport of Client
+		// and Transport, a nil result keeps them, and a request level Transport takes precedence.
+		// Redirects to other hosts use the transport selected for the first one.
+		TransportFor func(host string) http.RoundTripper
+
+		transportsMu sync.Mutex
+		transports   map[string]http.RoundTripper
+
+		// Retry configures automatic retries, see RetryConfig.
+		Retry *RetryConfig
+
+		// RequestIDHeader enables request ID propagation for every request when set.
+		// A request ID is generated into this header unless the caller already set one.
+		RequestIDHeader string
+
+		// DebugBodyLimit is the number of request body bytes written to the debug output of Surf.Debug,
+		// longer bodies are truncated. Zero logs up to 1KB and a negative value disables body logging.
+		DebugBodyLimit int
+
+		// Logger receives the logs written by Surf, such as WithSlowRequestLog.
+		// Defaults to the standard logger of the log package.
+		Logger Logger
+
 		Client *http.Client
 
+		// Transport is the transport used by every request. When both Client and Transport are set,
+		// Client is cloned with Transport replacing its own, keeping the other client settings.
+		// A request level Transport takes precedence over this one, and a request level Client keeps its own.
+		Transport http.RoundTripper
+
 		JSONMarshal   func(v interface{}) ([]byte, error)
 		JSONUnmarshal func(data []byte, v interface{}) error
 		XMLMarshal    func(v interface{}) ([]byte, error)
@@ -62,15 +169,33 @@ type (
 	// RequestConfig holds the configuration for a specific HTTP request.
 	RequestConfig struct {
 		BaseURL string
-		Url     string
-		Header  http.Header
-		Method  string
-		Cookies []*http.Cookie
+		// BasePath is a path prefix joined between BaseURL and a relative Url.
+		BasePath string
+		Url      string
+		Header   http.Header
+		Method   string
+		Cookies  []*http.Cookie
+
+		// Trailer is sent after the request body, which is then sent with chunked encoding, see WithTrailer.
+		Trailer http.Header
+
+		// HeaderOrder is the order in which headers should be written, see HeaderOrderFromContext.
+		HeaderOrder []string
 
 		Timeout time.Duration
 		Context context.Context
+		cancel  context.CancelFunc
+
+		// Delay is waited before the request is sent, see WithRequestDelay.
+		Delay time.Duration
+
+		// BodyReadTimeout limits the time to read the response body once the headers are received, see WithBodyReadTimeout.
+		BodyReadTimeout time.Duration
 
 		Params map[string]string
+		// AllowUnresolvedParams sends a path with :name segments left after substituting Params,
+		// see WithAllowUnresolvedParams.
+		AllowUnresolvedParams bool
 
 		Query           url.Values
 		QuerySerializer *QuerySerializer
@@ -78,21 +203,98 @@ type (
 		RequestInterceptors  []RequestInterceptor
 		ResponseInterceptors []ResponseInterceptor
 
+		// RequestModifiers modify the raw http.Request once it is fully prepared.
+		RequestModifiers []RequestModifier
+		// AttemptModifiers modify a copy of every http.Request sent, including retries and redirects.
+		AttemptModifiers []RequestModifier
+
+		// ResponseValidators run after the response interceptors.
+		ResponseValidators []ResponseValidator
+		// ResultValidators run after the Result is decoded, see WithResultValidator.
+		ResultValidators []ResultValidator
+
 		requestInterceptorsMu  sync.Mutex
 		responseInterceptorsMu sync.Mutex
 
 		// Body Request body, the request body type will automatically set the content-type.
 		// When processing file uploads, you can pass in the structure returned by NewMultipartFile.
+		// A body implementing io.Closer, such as an *os.File, is owned by Surf once the request is made
+		// and closed when the request is done, including when it fails before being sent.
+		// A map[string]interface{} sent with the form content type is encoded with nested bracketed keys,
+		// such as user[tags][0]=a, as expected by PHP and Rails.
 		Body interface{}
 
+		// CompressionLevel is the gzip level compressing the request body, see WithCompressionLevel.
+		CompressionLevel int
+
+		// StreamJSON encodes the body while it is sent instead of marshaling it first, see WithStreamJSON.
+		StreamJSON bool
+
+		// bodyDirty forces the body to be encoded again after the request interceptors, see MarkBodyDirty.
+		bodyDirty bool
+
+		// Metadata carries arbitrary values for interceptors and hooks, e.g. an operation name for metrics.
+		// It is not sent with the request.
+		Metadata map[string]interface{}
+
+		// Stream leaves the response body unread, see WithStream.
+		Stream bool
+
+		// RawResponse leaves the body of OriginalResponse unread and undecoded, see WithResponseBodyConsumed.
+		RawResponse bool
+
+		// StrictDecoding fails responses with an unsupported Content-Encoding, see WithStrictDecoding.
+		StrictDecoding bool
+
+		// AutoReferer chains the Referer across the requests of the Surf instance, see WithAutoReferer.
+		AutoReferer bool
+
+		// DecompressFallback keeps the raw body when it cannot be decoded, see WithDecompressFallback.
+		DecompressFallback bool
+
+		// decoders override the registered decoders by Content-Encoding for this request,
+		// e.g. set by WithBrotliReaderConfig.
+		decoders map[string]ContentDecoder
+
+		// BodyBufferPool provides the *bytes.Buffer the response body is read into, see WithResponseBodyBuffer.
+		BodyBufferPool *sync.Pool
+
+		// Result is decoded from the body of a successful (2xx) response, see Response.Unmarshal.
+		Result interface{}
+		// ErrorResult is decoded from the body of a non-2xx response, see Response.Unmarshal.
+		ErrorResult interface{}
+
 		MaxBodyLength int
 		MaxRedirects  int
 
+		// Retry configures automatic retries, unset fields are taken from Config.Retry.
+		Retry *RetryConfig
+
+		// RequestIDHeader enables request ID propagation when set, see Config.RequestIDHeader.
+		RequestIDHeader string
+		requestID       string
+
+		// Logger receives the logs written by Surf, including the debug output, see WithLogger.
+		// Defaults to the Logger of the context, see ContextWithLogger, then to Config.Logger.
+		Logger Logger
+
 		Client  *http.Client
 		Request *http.Request
 
+		// Transport overrides the transport of Client for this request only,
+		// the client is cloned so its other settings are kept.
+		Transport http.RoundTripper
+
+		// transportModifiers customize a per-request clone of the transport,
+		// which is closed once the request is done.
+		transportModifiers []func(t *http.Transport)
+		requestTransport   *http.Transport
+
 		clientTrace *clientTrace
 
+		// err records an error raised while applying options, returned before the request is sent.
+		err error
+
 		JSONMarshal   func(v interface{}) ([]byte, error)
 		JSONUnmarshal func(data []byte, v interface{}) error
 		XMLMarshal    func(v interface{}) ([]byte, error)
@@ -100,6 +302,13 @@ type (
 	}
 )
 
+const (
+	// RunOnce runs a RequestModifier once on the prepared request, see WithRequestModifier.
+	RunOnce ModifierRun = iota
+	// RunAlways runs a RequestModifier on every request sent, see WithRequestModifier.
+	RunAlways
+)
+
 // DefaultConfig is the default configuration for Surf.
 var DefaultConfig = &Config{
 	Client: http.DefaultClient,
@@ -109,19 +318,31 @@ var DefaultConfig = &Config{
 func (rc *RequestConfig) BuildURL() string {
 	baseURL := rc.BaseURL
 
-	if baseURL == "" {
+	// An absolute Url ignores BaseURL, but Params and Query still apply
+	if strings.Contains(rc.Url, "://") {
 		return rc.appendQueryToURL(rc.Url)
 	}
 
-	if strings.Contains(rc.Url, "://") {
-		return rc.Url
+	// Split off the query strings so that the path is joined before them
+	baseURL, baseQuery, _ := strings.Cut(baseURL, "?")
+	urlPath, urlQuery, _ := strings.Cut(rc.Url, "?")
+
+	if rc.BasePath != "" {
+		urlPath = strings.TrimRight(rc.BasePath, "/") + "/" + strings.TrimLeft(urlPath, "/")
+	}
+
+	if baseURL == "" {
+		if urlQuery != "" {
+			urlPath += "?" + urlQuery
+		}
+		return rc.appendQueryToURL(urlPath)
 	}
 
 	if !strings.HasSuffix(baseURL, "/") {
 		baseURL += "/"
 	}
 
-	urlPath := strings.TrimLeft(rc.Url, "/")
+	urlPath = strings.TrimLeft(urlPath, "/")
 
 	if !strings.Contains(baseURL, "://") {
 		baseURL = "https://" + baseURL
@@ -132,6 +353,15 @@ func (rc *RequestConfig) BuildURL() string {
 		return ""
 	}
 
+	// Merge the query strings of BaseURL and Url
+	var rawQuery []string
+	for _, q := range []string{baseQuery, urlQuery} {
+		if q != "" {
+			rawQuery = append(rawQuery, q)
+		}
+	}
+	u.RawQuery = strings.Join(rawQuery, "&")
+
 	return rc.appendQueryToURL(u.String())
 }
 
@@ -175,12 +405,29 @@ func (rc *RequestConfig) SetHeader(key, value string) *RequestConfig {
 	return rc
 }
 
+// SetMetadata sets a metadata value in the request configuration.
+func (rc *RequestConfig) SetMetadata(key string, value interface{}) *RequestConfig {
+	if rc.Metadata == nil {
+		rc.Metadata = make(map[string]interface{})
+	}
+	rc.Metadata[key] = value
+	return rc
+}
+
 // SetBody sets a body in the request configuration.
 func (rc *RequestConfig) SetBody(body interface{}) *RequestConfig {
 	rc.Body = body
 	return rc
 }
 
+// MarkBodyDirty makes the body be encoded again after the request interceptors, for a request
+// interceptor that edits the body in place. Struct and map bodies encoded as JSON or XML are
+// encoded again automatically whenever request interceptors are configured.
+func (rc *RequestConfig) MarkBodyDirty() *RequestConfig {
+	rc.bodyDirty = true
+	return rc
+}
+
 // SetCookie adds a cookie to the request configuration.
 func (rc *RequestConfig) SetCookie(cookie *http.Cookie) *RequestConfig {
 	rc.Cookies = append(rc.Cookies, cookie)
@@ -205,6 +452,117 @@ func (rc *RequestConfig) SetUrl(url string) *RequestConfig {
 	return rc
 }
 
+// RequestID returns the request ID sent with the request, empty if request ID propagation is disabled.
+func (rc *RequestConfig) RequestID() string {
+	return rc.requestID
+}
+
+// SentBody returns the body bytes of the prepared request, e.g. to log or verify a signed request.
+// It returns nil before the request is prepared and for bodies that cannot be replayed,
+// such as an io.Reader or a BodyWriter.
+func (rc *RequestConfig) SentBody() []byte {
+	if rc.Request == nil || rc.Request.GetBody == nil {
+		return nil
+	}
+	body, err := rc.Request.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// setRequestID ensures the request carries a request ID, keeping one already set by the caller.
+func (rc *RequestConfig) setRequestID(req *http.Request) {
+	if rc.RequestIDHeader == "" {
+		return
+	}
+	id := req.Header.Get(rc.RequestIDHeader)
+	if id == "" {
+		id = generateRequestID()
+		req.Header.Set(rc.RequestIDHeader, id)
+	}
+	rc.requestID = id
+}
+
+// setDecoder overrides the decoder of the given Content-Encoding for this request.
+func (rc *RequestConfig) setDecoder(encoding string, fn ContentDecoder) {
+	if rc.decoders == nil {
+		rc.decoders = make(map[string]ContentDecoder)
+	}
+	rc.decoders[strings.ToLower(strings.TrimSpace(encoding))] = fn
+}
+
+// withDefaults returns a copy of rc with the fields left unset filled from defaults, built from
+// Surf.SetDefaultOptions. Maps are merged key by key and the slices of defaults, such as interceptors,
+// are placed first, except HeaderOrder which is only taken when unset. rc itself is left untouched
+// so that a RequestConfig can be reused.
+func (rc *RequestConfig) withDefaults(defaults *RequestConfig) *RequestConfig {
+	merged := &RequestConfig{
+		bodyDirty: rc.bodyDirty,
+		err:       defaultValue(rc.err, defaults.err),
+		Retry:     rc.Retry.merge(defaults.Retry),
+	}
+
+	dst, src, def := reflect.ValueOf(merged).Elem(), reflect.ValueOf(rc).Elem(), reflect.ValueOf(defaults).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		field, value, defaultValue := dst.Field(i), src.Field(i), def.Field(i)
+		// Unexported fields are set above and below
+		if !field.CanSet() || dst.Type().Field(i).Name == "Retry" {
+			continue
+		}
+		switch {
+		case field.Kind() == reflect.Map:
+			// New maps so that neither the request nor the defaults maps are written into
+			if value.IsNil() && defaultValue.IsNil() {
+				continue
+			}
+			field.Set(reflect.MakeMapWithSize(field.Type(), value.Len()+defaultValue.Len()))
+			for _, m := range []reflect.Value{defaultValue, value} {
+				iter := m.MapRange()
+				for iter.Next() {
+					field.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+		case field.Kind() == reflect.Slice && dst.Type().Field(i).Name != "HeaderOrder":
+			if value.Len()+defaultValue.Len() == 0 {
+				field.Set(value)
+				continue
+			}
+			slice := reflect.MakeSlice(field.Type(), 0, value.Len()+defaultValue.Len())
+			field.Set(reflect.AppendSlice(reflect.AppendSlice(slice, defaultValue), value))
+		case value.IsZero():
+			field.Set(defaultValue)
+		default:
+			field.Set(value)
+		}
+	}
+
+	for encoding, fn := range defaults.decoders {
+		merged.setDecoder(encoding, fn)
+	}
+	for encoding, fn := range rc.decoders {
+		merged.setDecoder(encoding, fn)
+	}
+	merged.transportModifiers = append(append([]func(t *http.Transport){}, defaults.transportModifiers...), rc.transportModifiers...)
+	return merged
+}
+
+// releaseContext releases the resources of the deadline context and transport created by mergeConfig.
+func (rc *RequestConfig) releaseContext() {
+	if rc.cancel != nil {
+		rc.cancel()
+	}
+	if rc.requestTransport != nil {
+		rc.requestTransport.CloseIdleConnections()
+	}
+}
+
 // appendQueryToURL appends query parameters to the URL in the request configuration.
 func (rc *RequestConfig) appendQueryToURL(u string) string {
 	if rc.Params != nil {
@@ -242,29 +600,59 @@ func (rc *RequestConfig) getRequestBody() (r io.Reader, err error) {
 		if err == nil {
 			rc.SetHeader(headerContentType, data.FormDataContentType())
 		}
+		if err == nil && data.gzip {
+			if b, err = gzipBytes(b, rc.CompressionLevel); err == nil {
+				rc.SetHeader(headerContentEncoding, "gzip")
+			}
+		}
 		return bytes.NewReader(b), err
 	case url.Values:
 		return bytes.NewReader([]byte(data.Encode())), nil
+	case *TypedBody:
+		b, encErr := data.encode(rc)
+		if encErr != nil {
+			return nil, encodeBodyError(data.value, encErr)
+		}
+		if rc.Header.Get(headerContentType) == "" {
+			rc.SetHeader(headerContentType, data.contentType)
+		}
+		return bytes.NewReader(b), nil
 	case string:
 		return bytes.NewReader([]byte(data)), err
+	case BodyWriter:
+		return newPipeBody(data), nil
+	case func(w io.Writer) error:
+		return newPipeBody(data), nil
 	default:
 		contentType := rc.Header.Get(headerContentType)
 		if contentType != "" {
 			if regXmlHeader.MatchString(contentType) {
 				xmlData, xmlErr := rc.XMLMarshal(data)
 				if xmlErr != nil {
-					return nil, xmlErr
+					return nil, encodeBodyError(data, xmlErr)
 				}
 				return bytes.NewReader(xmlData), nil
 			}
 
 			if regJsonHeader.MatchString(contentType) {
+				if rc.StreamJSON {
+					return newPipeBody(func(w io.Writer) error {
+						if err := json.NewEncoder(w).Encode(data); err != nil {
+							return encodeBodyError(data, err)
+						}
+						return nil
+					}), nil
+				}
 				jsonData, jsonErr := rc.JSONMarshal(data)
 				if jsonErr != nil {
-					return nil, jsonErr
+					return nil, encodeBodyError(data, jsonErr)
 				}
 				return bytes.NewReader(jsonData), nil
 			}
+
+			if m, ok := data.(map[string]interface{}); ok && regFormHeader.MatchString(contentType) {
+				return bytes.NewReader([]byte(nestedFormValues(m).Encode())), nil
+			}
 		}
 
 		return nil, ErrRequestDataTypeInvalid
@@ -282,7 +670,7 @@ func (rc *RequestConfig) setContentTypeHeader() {
 		rc.SetHeader(headerContentType, defaultTextContentType)
 	case []byte:
 		rc.SetHeader(headerContentType, defaultStreamContentType)
-	case io.Reader, multipartFile:
+	case io.Reader, multipartFile, BodyWriter, func(w io.Writer) error:
 		// Do nothing, assuming the user has set the appropriate Content-Type
 	case url.Values:
 		// For form data, set Content-Type as application/x-www-form-urlencoded
@@ -299,10 +687,45 @@ func (rc *RequestConfig) mergeConfig(config *Config) *RequestConfig {
 		rc.BaseURL = config.BaseURL
 	}
 
-	if rc.Client == nil {
+	if rc.BasePath == "" {
+		rc.BasePath = config.BasePath
+	}
+
+	// A client supplied with the request keeps its transport unless the request overrides it
+	requestClient := rc.Client != nil
+	if !requestClient {
 		rc.Client = defaultValue(config.Client, http.DefaultClient)
 	}
 
+	if rc.Transport == nil && config.TransportFor != nil {
+		if u, err := url.Parse(rc.BuildURL()); err == nil {
+			rc.Transport = config.transportFor(u.Host)
+		}
+	}
+
+	if rc.Transport == nil && !requestClient {
+		rc.Transport = config.ownedTransport()
+	}
+
+	if len(rc.transportModifiers) > 0 {
+		rc.applyTransportModifiers()
+	}
+
+	if rc.Transport != nil {
+		client := *rc.Client
+		client.Transport = rc.Transport
+		rc.Client = &client
+	}
+
+	if len(rc.AttemptModifiers) > 0 {
+		client := *rc.Client
+		client.Transport = &modifierTransport{
+			base:      defaultValue[http.RoundTripper](client.Transport, http.DefaultTransport),
+			modifiers: rc.AttemptModifiers,
+		}
+		rc.Client = &client
+	}
+
 	if rc.Timeout == 0 {
 		rc.Timeout = config.Timeout
 	}
@@ -311,10 +734,6 @@ func (rc *RequestConfig) mergeConfig(config *Config) *RequestConfig {
 		rc.Client.Jar = *config.CookieJar
 	}
 
-	if rc.Timeout != 0 {
-		rc.Client.Timeout = rc.Timeout
-	}
-
 	if rc.Method == "" {
 		rc.Method = http.MethodGet
 	}
@@ -327,10 +746,31 @@ func (rc *RequestConfig) mergeConfig(config *Config) *RequestConfig {
 		rc.Context = context.Background()
 	}
 
+	// The effective deadline is the earlier of the context deadline and the timeout
+	if rc.Timeout > 0 {
+		deadline := time.Now().Add(rc.Timeout)
+		if d, ok := rc.Context.Deadline(); !ok || deadline.Before(d) {
+			rc.Context, rc.cancel = context.WithDeadline(rc.Context, deadline)
+		}
+	}
+
 	if rc.MaxBodyLength == 0 {
 		rc.MaxBodyLength = config.MaxBodyLength
 	}
 
+	if rc.RequestIDHeader == "" {
+		rc.RequestIDHeader = config.RequestIDHeader
+	}
+
+	if rc.Logger == nil {
+		rc.Logger = LoggerFromContext(rc.Context)
+	}
+	if rc.Logger == nil {
+		rc.Logger = defaultValue[Logger](config.Logger, log.Default())
+	}
+
+	rc.Retry = rc.Retry.merge(config.Retry)
+
 	if config.Params != nil {
 		for key, val := range config.Params {
 			if _, ok := rc.Params[key]; !ok {
@@ -349,18 +789,27 @@ func (rc *RequestConfig) mergeConfig(config *Config) *RequestConfig {
 		}
 	}
 
+	jsonMarshal, jsonUnmarshal := defaultJSONCodec()
 	if rc.JSONMarshal == nil {
-		rc.JSONMarshal = defaultValue(config.JSONMarshal, json.Marshal)
+		rc.JSONMarshal = defaultValue(config.JSONMarshal, jsonMarshal)
 	}
 	if rc.JSONUnmarshal == nil {
-		rc.JSONUnmarshal = defaultValue(config.JSONUnmarshal, json.Unmarshal)
+		rc.JSONUnmarshal = defaultValue(config.JSONUnmarshal, jsonUnmarshal)
 	}
 
+	xmlMarshal, xmlUnmarshal := defaultXMLCodec()
 	if rc.XMLMarshal == nil {
-		rc.XMLMarshal = defaultValue(config.XMLMarshal, xml.Marshal)
+		rc.XMLMarshal = defaultValue(config.XMLMarshal, xmlMarshal)
 	}
 	if rc.XMLUnmarshal == nil {
-		rc.XMLUnmarshal = defaultValue(config.XMLUnmarshal, xml.Unmarshal)
+		rc.XMLUnmarshal = defaultValue(config.XMLUnmarshal, xmlUnmarshal)
+	}
+
+	if len(rc.HeaderOrder) > 0 {
+		rc.Context = withHeaderOrder(rc.Context, rc.HeaderOrder)
+	}
+	if len(rc.decoders) > 0 {
+		rc.Context = withDecoders(rc.Context, rc.decoders)
 	}
 
 	// Enable http trace for Performance
@@ -369,6 +818,30 @@ func (rc *RequestConfig) mergeConfig(config *Config) *RequestConfig {
 	return rc
 }
 
+// invokeResponseValidators runs the response validators, returning the first error.
+func (rc *RequestConfig) invokeResponseValidators(resp *Response) error {
+	for _, fn := range rc.ResponseValidators {
+		if err := fn(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invokeResultValidators runs the result validators on the Result of a successful response,
+// returning the first error.
+func (rc *RequestConfig) invokeResultValidators(resp *Response) error {
+	if rc.Result == nil || rc.Stream || rc.RawResponse || !resp.Ok() {
+		return nil
+	}
+	for _, fn := range rc.ResultValidators {
+		if err := fn(rc.Result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // AppendRequestInterceptors appends request interceptors to the interceptor list.
 func (rc *RequestConfig) AppendRequestInterceptors(interceptors ...RequestInterceptor) *RequestConfig {
 	rc.requestInterceptorsMu.Lock()
@@ -445,6 +918,138 @@ func (rc *RequestConfig) invokeResponseInterceptors(resp *Response) (err error)
 	return
 }
 
+// clone creates a deep copy of the configuration.
+func (c *Config) clone() *Config {
+	return &Config{
+		BaseURL:                      c.BaseURL,
+		BasePath:                     c.BasePath,
+		Header:                       c.Header.Clone(),
+		Timeout:                      c.Timeout,
+		Params:                       cloneMap(c.Params),
+		Query:                        cloneURLValues(c.Query),
+		Cookies:                      append([]*http.Cookie(nil), c.Cookies...),
+		CookieJar:                    c.CookieJar,
+		AcceptLanguage:               append([]string(nil), c.AcceptLanguage...),
+		QuerySerializer:              c.QuerySerializer,
+		RequestInterceptors:          append([]RequestInterceptor(nil), c.RequestInterceptors...),
+		ResponseInterceptors:         append([]ResponseInterceptor(nil), c.ResponseInterceptors...),
+		MaxBodyLength:                c.MaxBodyLength,
+		MaxRedirects:                 c.MaxRedirects,
+		DefaultReadBufferSize:        c.DefaultReadBufferSize,
+		MaxConcurrentRequests:        c.MaxConcurrentRequests,
+		MaxConcurrentRequestsPerHost: c.MaxConcurrentRequestsPerHost,
+		MaxConnsPerHost:              c.MaxConnsPerHost,
+		IdleConnTimeout:              c.IdleConnTimeout,
+		KeepAlive:                    c.KeepAlive,
+		TrackInFlight:                c.TrackInFlight,
+		AutoReferer:                  c.AutoReferer,
+		OnResponseHeaders:            c.OnResponseHeaders,
+		RequestIDHeader:              c.RequestIDHeader,
+		DebugBodyLimit:               c.DebugBodyLimit,
+		Logger:                       c.Logger,
+		Retry:                        c.Retry.clone(),
+		Client:                       c.Client,
+		Transport:                    c.Transport,
+		TransportFor:                 c.TransportFor,
+		JSONMarshal:                  c.JSONMarshal,
+		JSONUnmarshal:                c.JSONUnmarshal,
+		XMLMarshal:                   c.XMLMarshal,
+		XMLUnmarshal:                 c.XMLUnmarshal,
+	}
+}
+
+// Merge returns a copy of the configuration with the non-zero fields of other applied onto it.
+// Header, Params and Query are merged key by key, other winning on conflicts.
+// Cookies and interceptors of other are appended after the ones of the receiver.
+// The other fields are replaced when set in other.
+func (c *Config) Merge(other *Config) *Config {
+	merged := c.clone()
+	if other == nil {
+		return merged
+	}
+
+	merged.BaseURL = defaultValue(other.BaseURL, merged.BaseURL)
+	merged.BasePath = defaultValue(other.BasePath, merged.BasePath)
+	merged.Timeout = defaultValue(other.Timeout, merged.Timeout)
+
+	for key, values := range other.Header {
+		if merged.Header == nil {
+			merged.Header = make(http.Header)
+		}
+		merged.Header[key] = append([]string(nil), values...)
+	}
+	for key, value := range other.Params {
+		merged.Params[key] = value
+	}
+	for key, values := range other.Query {
+		merged.Query[key] = append([]string(nil), values...)
+	}
+
+	merged.Cookies = append(merged.Cookies, other.Cookies...)
+	merged.RequestInterceptors = append(merged.RequestInterceptors, other.RequestInterceptors...)
+	merged.ResponseInterceptors = append(merged.ResponseInterceptors, other.ResponseInterceptors...)
+
+	if len(other.AcceptLanguage) > 0 {
+		merged.AcceptLanguage = append([]string(nil), other.AcceptLanguage...)
+	}
+	if other.CookieJar != nil {
+		merged.CookieJar = other.CookieJar
+	}
+	if other.QuerySerializer != nil {
+		merged.QuerySerializer = other.QuerySerializer
+	}
+	if other.Client != nil {
+		merged.Client = other.Client
+	}
+	if other.OnResponseHeaders != nil {
+		merged.OnResponseHeaders = other.OnResponseHeaders
+	}
+	if other.Transport != nil {
+		merged.Transport = other.Transport
+	}
+	if other.TransportFor != nil {
+		merged.TransportFor = other.TransportFor
+	}
+
+	merged.MaxBodyLength = defaultValue(other.MaxBodyLength, merged.MaxBodyLength)
+	merged.MaxRedirects = defaultValue(other.MaxRedirects, merged.MaxRedirects)
+	merged.DefaultReadBufferSize = defaultValue(other.DefaultReadBufferSize, merged.DefaultReadBufferSize)
+	merged.MaxConcurrentRequests = defaultValue(other.MaxConcurrentRequests, merged.MaxConcurrentRequests)
+	merged.MaxConcurrentRequestsPerHost = defaultValue(other.MaxConcurrentRequestsPerHost, merged.MaxConcurrentRequestsPerHost)
+	merged.MaxConnsPerHost = defaultValue(other.MaxConnsPerHost, merged.MaxConnsPerHost)
+	merged.IdleConnTimeout = defaultValue(other.IdleConnTimeout, merged.IdleConnTimeout)
+	merged.KeepAlive = defaultValue(other.KeepAlive, merged.KeepAlive)
+	merged.TrackInFlight = other.TrackInFlight || merged.TrackInFlight
+	merged.AutoReferer = other.AutoReferer || merged.AutoReferer
+	merged.RequestIDHeader = defaultValue(other.RequestIDHeader, merged.RequestIDHeader)
+	merged.DebugBodyLimit = defaultValue(other.DebugBodyLimit, merged.DebugBodyLimit)
+	merged.Logger = defaultValue(other.Logger, merged.Logger)
+	merged.Retry = other.Retry.merge(merged.Retry)
+
+	if other.JSONMarshal != nil {
+		merged.JSONMarshal = other.JSONMarshal
+	}
+	if other.JSONUnmarshal != nil {
+		merged.JSONUnmarshal = other.JSONUnmarshal
+	}
+	if other.XMLMarshal != nil {
+		merged.XMLMarshal = other.XMLMarshal
+	}
+	if other.XMLUnmarshal != nil {
+		merged.XMLUnmarshal = other.XMLUnmarshal
+	}
+
+	return merged
+}
+
+// hasRequestInterceptors reports whether any request interceptor is configured.
+func (c *Config) hasRequestInterceptors() bool {
+	c.requestInterceptorsMu.RLock()
+	defer c.requestInterceptorsMu.RUnlock()
+
+	return len(c.RequestInterceptors) > 0
+}
+
 // invokeRequestInterceptors invokes all request interceptors with the provided configuration.
 func (c *Config) invokeRequestInterceptors(config *RequestConfig) (err error) {
 	c.requestInterceptorsMu.Lock()
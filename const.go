@@ -9,25 +9,39 @@ const Version = "0.0.1"
 
 const (
 	UserAgent                = "surf/" + Version + " (https://github.com/fupengl/surf)"
-	defaultAcceptEncoding    = "gzip, deflate, br"
 	defaultAccept            = "application/json, text/plain, */*"
+	defaultXmlAccept         = "application/xml, text/xml, */*"
 	defaultJsonContentType   = "application/json; charset=UTF-8"
+	defaultXmlContentType    = "application/xml; charset=UTF-8"
 	defaultTextContentType   = "text/plain; charset=UTF-8"
 	defaultStreamContentType = "application/octet-stream"
 	defaultFormContentType   = "application/x-www-form-urlencoded; charset=UTF-8"
+	defaultReadBufferSize    = 16 * 1024
+	defaultDebugBodyLimit    = 1024
 )
 
 var (
-	headerUserAgent       = http.CanonicalHeaderKey("User-Agent")
-	headerAcceptEncoding  = http.CanonicalHeaderKey("Accept-Encoding")
-	headerAccept          = http.CanonicalHeaderKey("Accept")
-	headerLocation        = http.CanonicalHeaderKey("Location")
-	headerContentEncoding = http.CanonicalHeaderKey("Content-Encoding")
-	headerContentType     = http.CanonicalHeaderKey("Content-Type")
-	headerContentLength   = http.CanonicalHeaderKey("Content-Length")
+	headerUserAgent          = http.CanonicalHeaderKey("User-Agent")
+	headerAcceptEncoding     = http.CanonicalHeaderKey("Accept-Encoding")
+	headerAccept             = http.CanonicalHeaderKey("Accept")
+	headerLocation           = http.CanonicalHeaderKey("Location")
+	headerContentEncoding    = http.CanonicalHeaderKey("Content-Encoding")
+	headerContentType        = http.CanonicalHeaderKey("Content-Type")
+	headerContentLength      = http.CanonicalHeaderKey("Content-Length")
+	headerRequestID          = http.CanonicalHeaderKey("X-Request-ID")
+	headerReferer            = http.CanonicalHeaderKey("Referer")
+	headerOrigin             = http.CanonicalHeaderKey("Origin")
+	headerAcceptLanguage     = http.CanonicalHeaderKey("Accept-Language")
+	headerIdempotencyKey     = http.CanonicalHeaderKey("Idempotency-Key")
+	headerContentDisposition = http.CanonicalHeaderKey("Content-Disposition")
+	headerRequestTimeout     = http.CanonicalHeaderKey("X-Request-Timeout")
+	headerExpect             = http.CanonicalHeaderKey("Expect")
+	headerIfMatch            = http.CanonicalHeaderKey("If-Match")
 )
 
 var (
 	regJsonHeader = regexp.MustCompile(`(?i:(application|text)/(.*json.*)(;|$))`)
 	regXmlHeader  = regexp.MustCompile(`(?i:(application|text)/(.*xml.*)(;|$))`)
+	regFormHeader = regexp.MustCompile(`(?i:application/x-www-form-urlencoded(;|$))`)
+	regPathParam  = regexp.MustCompile(`(?:^|/)(:[A-Za-z_]\w*)`)
 )
@@ -0,0 +1,49 @@
+package surf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ExportCookies returns the cookies the jar would send to u.
+// Note that http.CookieJar implementations usually only return the name and value of the cookies.
+func ExportCookies(jar http.CookieJar, u *url.URL) []*http.Cookie {
+	if jar == nil {
+		return nil
+	}
+	return jar.Cookies(u)
+}
+
+// ImportCookies stores the cookies in the jar for u, skipping the ones already expired.
+func ImportCookies(jar http.CookieJar, u *url.URL, cookies []*http.Cookie) {
+	if jar == nil {
+		return
+	}
+
+	now := time.Now()
+	valid := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		if cookie == nil || cookie.MaxAge < 0 {
+			continue
+		}
+		if !cookie.Expires.IsZero() && !cookie.Expires.After(now) {
+			continue
+		}
+		valid = append(valid, cookie)
+	}
+	jar.SetCookies(u, valid)
+}
+
+// MarshalCookies encodes cookies as JSON, for example to persist a session exported with ExportCookies.
+func MarshalCookies(cookies []*http.Cookie) ([]byte, error) {
+	return json.Marshal(cookies)
+}
+
+// UnmarshalCookies decodes cookies encoded with MarshalCookies.
+func UnmarshalCookies(data []byte) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+	err := json.Unmarshal(data, &cookies)
+	return cookies, err
+}
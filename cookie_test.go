@@ -0,0 +1,34 @@
+package surf
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestExportImportCookies(t *testing.T) {
+	u, _ := url.Parse("https://example.com")
+
+	src, _ := cookiejar.New(nil)
+	src.SetCookies(u, []*http.Cookie{{Name: "session", Value: "abc"}})
+
+	data, err := MarshalCookies(ExportCookies(src, u))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookies, err := UnmarshalCookies(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookies = append(cookies, &http.Cookie{Name: "expired", Value: "1", Expires: time.Now().Add(-time.Hour)})
+
+	dst, _ := cookiejar.New(nil)
+	ImportCookies(dst, u, cookies)
+
+	imported := dst.Cookies(u)
+	if len(imported) != 1 || imported[0].Name != "session" || imported[0].Value != "abc" {
+		t.Fatalf("imported cookies unexpected %v.", imported)
+	}
+}
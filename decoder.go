@@ -0,0 +1,47 @@
+package surf
+
+import (
+	"mime"
+	"strings"
+	"sync"
+)
+
+// ResponseDecoder decodes a response body into the provided variable (v).
+type ResponseDecoder func(data []byte, v interface{}) error
+
+var (
+	responseDecoders   = make(map[string]ResponseDecoder)
+	responseDecodersMu sync.RWMutex
+)
+
+// RegisterResponseDecoder registers a decoder used by Response.Unmarshal for the given content type.
+// The content type is matched by media type only, parameters such as charset are ignored.
+func RegisterResponseDecoder(contentType string, fn ResponseDecoder) {
+	responseDecodersMu.Lock()
+	defer responseDecodersMu.Unlock()
+
+	mediaType := parseMediaType(contentType)
+	if fn == nil {
+		delete(responseDecoders, mediaType)
+		return
+	}
+	responseDecoders[mediaType] = fn
+}
+
+// lookupResponseDecoder returns the registered decoder for the given content type.
+func lookupResponseDecoder(contentType string) (ResponseDecoder, bool) {
+	responseDecodersMu.RLock()
+	defer responseDecodersMu.RUnlock()
+
+	fn, ok := responseDecoders[parseMediaType(contentType)]
+	return fn, ok
+}
+
+// parseMediaType returns the lower-cased media type of a Content-Type value.
+func parseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
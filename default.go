@@ -0,0 +1,56 @@
+package surf
+
+// Request performs an HTTP request with the Default instance.
+func Request(config *RequestConfig) (*Response, error) {
+	return Default.Request(config)
+}
+
+// Upload performs a file upload with the Default instance.
+func Upload(url string, file *multipartFile, args ...WithRequestConfig) (*Response, error) {
+	return Default.Upload(url, file, args...)
+}
+
+// Get performs a GET request with the Default instance.
+func Get(url string, args ...WithRequestConfig) (*Response, error) {
+	return Default.Get(url, args...)
+}
+
+// Post performs a POST request with the Default instance.
+func Post(url string, args ...WithRequestConfig) (*Response, error) {
+	return Default.Post(url, args...)
+}
+
+// Head performs a HEAD request with the Default instance.
+func Head(url string, args ...WithRequestConfig) (*Response, error) {
+	return Default.Head(url, args...)
+}
+
+// Put performs a PUT request with the Default instance.
+func Put(url string, args ...WithRequestConfig) (*Response, error) {
+	return Default.Put(url, args...)
+}
+
+// Patch performs a PATCH request with the Default instance.
+func Patch(url string, args ...WithRequestConfig) (*Response, error) {
+	return Default.Patch(url, args...)
+}
+
+// Delete performs a DELETE request with the Default instance.
+func Delete(url string, args ...WithRequestConfig) (*Response, error) {
+	return Default.Delete(url, args...)
+}
+
+// Options performs an OPTIONS request with the Default instance.
+func Options(url string, args ...WithRequestConfig) (*Response, error) {
+	return Default.Options(url, args...)
+}
+
+// Connect performs a CONNECT request with the Default instance.
+func Connect(url string, args ...WithRequestConfig) (*Response, error) {
+	return Default.Connect(url, args...)
+}
+
+// Trace performs a TRACE request with the Default instance.
+func Trace(url string, args ...WithRequestConfig) (*Response, error) {
+	return Default.Trace(url, args...)
+}
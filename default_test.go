@@ -0,0 +1,54 @@
+package surf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		_, _ = w.Write([]byte(r.Method))
+	}))
+	defer server.Close()
+
+	methods := map[string]func(url string, args ...WithRequestConfig) (*Response, error){
+		http.MethodGet:     Get,
+		http.MethodPost:    Post,
+		http.MethodHead:    Head,
+		http.MethodPut:     Put,
+		http.MethodPatch:   Patch,
+		http.MethodDelete:  Delete,
+		http.MethodOptions: Options,
+		http.MethodConnect: Connect,
+		http.MethodTrace:   Trace,
+	}
+	for method, fn := range methods {
+		resp, err := fn(server.URL)
+		if err != nil {
+			t.Fatalf("%s expect no error output %v.", method, err)
+		}
+		if resp.Headers().Get("X-Method") != method {
+			t.Fatalf("%s expect sent output %s.", method, resp.Headers().Get("X-Method"))
+		}
+	}
+
+	resp, err := Request(&RequestConfig{Url: server.URL, Method: http.MethodPut})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != http.MethodPut {
+		t.Fatalf("request expect PUT output %s.", resp.Text())
+	}
+
+	m := NewMultipartFile(0)
+	m.AddFile("file", "a.txt", []byte("surf"))
+	resp, err = Upload(server.URL, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != http.MethodPost {
+		t.Fatalf("upload expect POST output %s.", resp.Text())
+	}
+}
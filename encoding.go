@@ -0,0 +1,111 @@
+package surf
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ContentDecoder wraps a response body encoded with a Content-Encoding into a decoded reader.
+// If the returned reader implements io.Closer it is closed once the body has been read.
+type ContentDecoder func(r io.Reader) (io.Reader, error)
+
+var (
+	contentDecoders      = make(map[string]ContentDecoder)
+	contentDecoderOrder  []string
+	contentDecodersMu    sync.RWMutex
+	contentEncodingAlias = map[string]string{
+		"x-gzip":     "gzip",
+		"x-compress": "compress",
+	}
+)
+
+func init() {
+	RegisterDecoder("gzip", func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	})
+	RegisterDecoder("deflate", func(r io.Reader) (io.Reader, error) {
+		return flate.NewReader(r), nil
+	})
+	// compress is decoded when a server sends it but not advertised, it is obsolete
+	registerDecoder("compress", newLZWReader, false)
+}
+
+// RegisterDecoder registers a decoder for the given Content-Encoding, replacing any existing one.
+// Registered encodings are advertised in the default Accept-Encoding header in registration order.
+// Passing a nil decoder removes the encoding.
+func RegisterDecoder(encoding string, fn ContentDecoder) {
+	registerDecoder(encoding, fn, true)
+}
+
+// registerDecoder registers a decoder, advertising it in Accept-Encoding when advertise is set.
+func registerDecoder(encoding string, fn ContentDecoder, advertise bool) {
+	contentDecodersMu.Lock()
+	defer contentDecodersMu.Unlock()
+
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+
+	if _, ok := contentDecoders[encoding]; ok {
+		for i, name := range contentDecoderOrder {
+			if name == encoding {
+				contentDecoderOrder = append(contentDecoderOrder[:i:i], contentDecoderOrder[i+1:]...)
+				break
+			}
+		}
+		delete(contentDecoders, encoding)
+	}
+
+	if fn == nil {
+		return
+	}
+
+	contentDecoders[encoding] = fn
+	if advertise {
+		contentDecoderOrder = append(contentDecoderOrder, encoding)
+	}
+}
+
+// lookupDecoder returns the decoder registered for the given Content-Encoding.
+func lookupDecoder(encoding string) (ContentDecoder, bool) {
+	contentDecodersMu.RLock()
+	defer contentDecodersMu.RUnlock()
+
+	if alias, ok := contentEncodingAlias[encoding]; ok {
+		encoding = alias
+	}
+	fn, ok := contentDecoders[encoding]
+	return fn, ok
+}
+
+type decodersKey struct{}
+
+// withDecoders returns a copy of ctx carrying per-request decoder overrides.
+func withDecoders(ctx context.Context, decoders map[string]ContentDecoder) context.Context {
+	return context.WithValue(ctx, decodersKey{}, decoders)
+}
+
+// lookupContextDecoder returns the decoder overridden for the request context,
+// falling back to the registered decoder.
+func lookupContextDecoder(ctx context.Context, encoding string) (ContentDecoder, bool) {
+	if decoders, ok := ctx.Value(decodersKey{}).(map[string]ContentDecoder); ok {
+		name := encoding
+		if alias, ok := contentEncodingAlias[name]; ok {
+			name = alias
+		}
+		if fn, ok := decoders[name]; ok {
+			return fn, true
+		}
+	}
+	return lookupDecoder(encoding)
+}
+
+// acceptEncoding returns the default Accept-Encoding header built from the registered decoders.
+func acceptEncoding() string {
+	contentDecodersMu.RLock()
+	defer contentDecodersMu.RUnlock()
+
+	return strings.Join(contentDecoderOrder, ", ")
+}
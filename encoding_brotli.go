@@ -0,0 +1,30 @@
+//go:build !surf_nobrotli
+
+package surf
+
+import (
+	"io"
+
+	"github.com/dsnet/compress/brotli"
+)
+
+// Brotli support can be left out of the binary with the surf_nobrotli build tag,
+// "br" is then no longer advertised in Accept-Encoding.
+func init() {
+	RegisterDecoder("br", func(r io.Reader) (io.Reader, error) {
+		return brotli.NewReader(r, nil)
+	})
+}
+
+// WithBrotliReaderConfig sets the configuration of the brotli reader decoding the response body,
+// nil keeps the default configuration.
+func WithBrotliReaderConfig(conf *brotli.ReaderConfig) WithRequestConfig {
+	return func(c *RequestConfig) {
+		if conf == nil {
+			return
+		}
+		c.setDecoder("br", func(r io.Reader) (io.Reader, error) {
+			return brotli.NewReader(r, conf)
+		})
+	}
+}
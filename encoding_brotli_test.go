@@ -0,0 +1,49 @@
+//go:build !surf_nobrotli
+
+package surf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dsnet/compress/brotli"
+)
+
+// brotliSurf is "surf" as a brotli stream with a single uncompressed meta-block.
+var brotliSurf = []byte{0x30, 0x00, 0x10, 's', 'u', 'r', 'f', 0x03}
+
+func TestAcceptEncoding_Brotli(t *testing.T) {
+	if ae := acceptEncoding(); ae != "gzip, deflate, br" {
+		t.Fatalf("accept encoding expect gzip, deflate, br output %s.", ae)
+	}
+}
+
+func TestWithBrotliReaderConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentEncoding, "br")
+		_, _ = w.Write(brotliSurf)
+	}))
+	defer server.Close()
+
+	s := New(nil)
+	for _, conf := range []*brotli.ReaderConfig{nil, {}} {
+		resp, err := s.Get(server.URL, WithBrotliReaderConfig(conf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != "surf" {
+			t.Fatalf("brotli body expect surf output %s.", resp.Text())
+		}
+	}
+
+	config := &RequestConfig{}
+	WithBrotliReaderConfig(nil)(config)
+	if config.decoders != nil {
+		t.Fatal("nil brotli config expect default decoder.")
+	}
+	WithBrotliReaderConfig(&brotli.ReaderConfig{})(config)
+	if _, ok := config.decoders["br"]; !ok {
+		t.Fatal("brotli config expect br decoder override.")
+	}
+}
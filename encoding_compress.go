@@ -0,0 +1,211 @@
+package surf
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+var errLZWCorrupt = errors.New("surf: corrupt compress (lzw) data")
+
+const (
+	lzwMagic0    = 0x1f
+	lzwMagic1    = 0x9d
+	lzwBitsMask  = 0x1f
+	lzwBlockMode = 0x80
+	lzwInitBits  = 9
+	lzwClear     = 256
+)
+
+// lzwReader decodes the "compress" Content-Encoding, the LZW format of the Unix compress utility.
+// compress/lzw cannot read it: the stream starts with a header, codes grow up to 16 bits and the
+// code stream is padded to a group of 8 codes whenever the code width changes or the table is cleared.
+type lzwReader struct {
+	r io.ByteReader
+
+	maxBits    uint
+	maxMaxCode int
+	blockMode  bool
+
+	nBits    uint
+	maxCode  int
+	freeEnt  int
+	oldCode  int
+	finChar  byte
+	bitBuf   uint32
+	bitCount uint
+	// codes is the number of codes read since the code width last changed, to pad to a group of 8.
+	codes int
+
+	prefix []uint16
+	suffix []byte
+	stack  []byte
+	buf    []byte
+	out    []byte
+	err    error
+}
+
+// newLZWReader reads the header of a compress stream and returns its decoder.
+func newLZWReader(r io.Reader) (io.Reader, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	var header [3]byte
+	for i := range header {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, errLZWCorrupt
+		}
+		header[i] = b
+	}
+	maxBits := uint(header[2] & lzwBitsMask)
+	if header[0] != lzwMagic0 || header[1] != lzwMagic1 || maxBits < lzwInitBits || maxBits > 16 {
+		return nil, errLZWCorrupt
+	}
+
+	z := &lzwReader{
+		r:          br,
+		maxBits:    maxBits,
+		maxMaxCode: 1 << maxBits,
+		blockMode:  header[2]&lzwBlockMode != 0,
+		oldCode:    -1,
+		prefix:     make([]uint16, 1<<maxBits),
+		suffix:     make([]byte, 1<<maxBits),
+	}
+	for i := 0; i < 256; i++ {
+		z.suffix[i] = byte(i)
+	}
+	z.resetWidth()
+	z.freeEnt = 256
+	if z.blockMode {
+		z.freeEnt = 257
+	}
+	return z, nil
+}
+
+// Read implements io.Reader.
+func (z *lzwReader) Read(p []byte) (int, error) {
+	for len(z.out) == 0 {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.err = z.decode()
+	}
+	n := copy(p, z.out)
+	z.out = z.out[n:]
+	return n, nil
+}
+
+// resetWidth restores the initial code width.
+func (z *lzwReader) resetWidth() {
+	z.nBits = lzwInitBits
+	z.maxCode = 1<<z.nBits - 1
+}
+
+// readCode reads the next code, io.EOF when fewer bits than the code width are left.
+func (z *lzwReader) readCode() (int, error) {
+	for z.bitCount < z.nBits {
+		b, err := z.r.ReadByte()
+		if err == io.EOF {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		z.bitBuf |= uint32(b) << z.bitCount
+		z.bitCount += 8
+	}
+	code := int(z.bitBuf & (1<<z.nBits - 1))
+	z.bitBuf >>= z.nBits
+	z.bitCount -= z.nBits
+	z.codes++
+	return code, nil
+}
+
+// align skips the padding codes completing the current group of 8 codes.
+func (z *lzwReader) align() error {
+	for z.codes%8 != 0 {
+		if _, err := z.readCode(); err != nil {
+			return err
+		}
+	}
+	z.codes = 0
+	return nil
+}
+
+// decode decodes the next code into out.
+func (z *lzwReader) decode() error {
+	if z.freeEnt > z.maxCode {
+		if err := z.align(); err != nil {
+			return err
+		}
+		z.nBits++
+		if z.nBits == z.maxBits {
+			z.maxCode = z.maxMaxCode
+		} else {
+			z.maxCode = 1<<z.nBits - 1
+		}
+	}
+
+	code, err := z.readCode()
+	if err != nil {
+		return err
+	}
+
+	if z.oldCode == -1 {
+		if code >= 256 {
+			return errLZWCorrupt
+		}
+		z.oldCode = code
+		z.finChar = byte(code)
+		z.buf = append(z.buf[:0], z.finChar)
+		z.out = z.buf
+		return nil
+	}
+
+	if code == lzwClear && z.blockMode {
+		if err := z.align(); err != nil {
+			return err
+		}
+		z.freeEnt = lzwClear
+		z.resetWidth()
+		return nil
+	}
+
+	inCode := code
+	stack := z.stack[:0]
+	if code >= z.freeEnt {
+		// The code being defined, the previous string followed by its own first byte
+		if code > z.freeEnt {
+			return errLZWCorrupt
+		}
+		stack = append(stack, z.finChar)
+		code = z.oldCode
+	}
+	for code >= 256 {
+		if len(stack) >= z.maxMaxCode {
+			return errLZWCorrupt
+		}
+		stack = append(stack, z.suffix[code])
+		code = int(z.prefix[code])
+	}
+	z.finChar = z.suffix[code]
+	stack = append(stack, z.finChar)
+	z.stack = stack
+
+	z.buf = z.buf[:0]
+	for i := len(stack) - 1; i >= 0; i-- {
+		z.buf = append(z.buf, stack[i])
+	}
+	z.out = z.buf
+
+	if z.freeEnt < z.maxMaxCode {
+		z.prefix[z.freeEnt] = uint16(z.oldCode)
+		z.suffix[z.freeEnt] = z.finChar
+		z.freeEnt++
+	}
+	z.oldCode = inCode
+	return nil
+}
@@ -0,0 +1,221 @@
+package surf
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRegisterDecoder(t *testing.T) {
+	RegisterDecoder("x-upper", func(r io.Reader) (io.Reader, error) {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(bytes.ToLower(b)), nil
+	})
+	defer RegisterDecoder("x-upper", nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get(headerAcceptEncoding), "x-upper") {
+			t.Errorf("accept encoding expect x-upper output %s.", r.Header.Get(headerAcceptEncoding))
+		}
+		w.Header().Set(headerContentEncoding, "x-upper")
+		_, _ = w.Write([]byte("SURF"))
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "surf" {
+		t.Fatalf("decoded body expect surf output %s.", resp.Text())
+	}
+}
+
+func TestAcceptEncoding(t *testing.T) {
+	// br is appended when brotli is built in, see TestAcceptEncoding_Brotli
+	if ae := acceptEncoding(); !strings.HasPrefix(ae, "gzip, deflate") {
+		t.Fatalf("accept encoding expect gzip, deflate output %s.", ae)
+	}
+}
+
+func TestReadBody_MultipleEncodings(t *testing.T) {
+	var buf bytes.Buffer
+	fw, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	_, _ = fw.Write([]byte("surf"))
+	_ = fw.Close()
+
+	var body bytes.Buffer
+	gw := gzip.NewWriter(&body)
+	_, _ = gw.Write(buf.Bytes())
+	_ = gw.Close()
+
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{headerContentEncoding: {"deflate, gzip"}},
+		Body:       io.NopCloser(&body),
+		Request:    &http.Request{Method: http.MethodGet},
+	}
+	data, err := readBody(res, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "surf" {
+		t.Fatalf("decoded body expect surf output %s.", data)
+	}
+}
+
+func TestReadBody_DecompressError(t *testing.T) {
+	var body bytes.Buffer
+	gw := gzip.NewWriter(&body)
+	_, _ = gw.Write([]byte("surf surf surf surf"))
+	_ = gw.Close()
+
+	// Keep the gzip header but corrupt the compressed data
+	corrupt := body.Bytes()
+	for i := 10; i < len(corrupt); i++ {
+		corrupt[i] = 0xff
+	}
+
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{headerContentEncoding: {"gzip"}},
+		Body:       io.NopCloser(bytes.NewReader(corrupt)),
+		Request:    &http.Request{Method: http.MethodGet},
+	}
+	_, err := readBody(res, 0, 0)
+	if !errors.Is(err, ErrDecompress) || errors.Is(err, ErrBodyRead) {
+		t.Fatalf("expect decompress error output %v.", err)
+	}
+	var bodyErr *BodyError
+	if !errors.As(err, &bodyErr) || bodyErr.Encoding != "gzip" || bodyErr.BytesRead == 0 {
+		t.Fatalf("body error unexpected %+v.", bodyErr)
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader([]byte("not gzip")))
+	_, err = readBody(res, 0, 0)
+	if !errors.Is(err, ErrDecompress) {
+		t.Fatalf("expect decompress error for invalid header output %v.", err)
+	}
+}
+
+func TestReadBody_Compress(t *testing.T) {
+	var expect strings.Builder
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&expect, "line %d: surf rides the %s wave\n", i, []string{"http", "lzw", "gzip", "brotli"}[i%4])
+	}
+
+	// Generated with 16 and 9 bit codes, the latter clearing the table, and checked with gzip -d
+	for _, name := range []string{"testdata/lines16.Z", "testdata/lines9.Z"} {
+		compressed, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, encoding := range []string{"compress", "x-compress"} {
+			res := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{headerContentEncoding: {encoding}},
+				Body:       io.NopCloser(bytes.NewReader(compressed)),
+				Request:    &http.Request{Method: http.MethodGet},
+			}
+			data, err := readBody(res, 0, 0)
+			if err != nil {
+				t.Fatalf("%s %s: %v", name, encoding, err)
+			}
+			if string(data) != expect.String() {
+				t.Fatalf("%s %s: decoded body expect %d bytes output %d.", name, encoding, expect.Len(), len(data))
+			}
+		}
+	}
+
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{headerContentEncoding: {"compress"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte{0x1f, 0x8b, 0x08})),
+		Request:    &http.Request{Method: http.MethodGet},
+	}
+	if _, err := readBody(res, 0, 0); !errors.Is(err, ErrDecompress) {
+		t.Fatalf("expect decompress error for a gzip body output %v.", err)
+	}
+	if ae := acceptEncoding(); strings.Contains(ae, "compress") {
+		t.Fatalf("accept encoding expect compress not advertised output %s.", ae)
+	}
+}
+
+func TestWithDecompressFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentEncoding, "gzip")
+		_, _ = w.Write([]byte("plain surf"))
+	}))
+	defer server.Close()
+
+	if _, err := New(nil).Get(server.URL); !errors.Is(err, ErrDecompress) {
+		t.Fatalf("expect decompress error output %v.", err)
+	}
+
+	logger := &recordLogger{}
+	resp, err := New(&Config{Logger: logger}).Get(server.URL, WithDecompressFallback())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.DecompressFailed() || resp.Text() != "plain surf" || len(logger.lines) != 1 {
+		t.Fatalf("expect raw body fallback output %v %s %q.", resp.DecompressFailed(), resp.Text(), logger.lines)
+	}
+
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, _ = gw.Write([]byte("surf"))
+	_ = gw.Close()
+	gzipServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentEncoding, "gzip")
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer gzipServer.Close()
+
+	resp, err = New(nil).Get(gzipServer.URL, WithDecompressFallback())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.DecompressFailed() || resp.Text() != "surf" {
+		t.Fatalf("expect decoded body output %v %s.", resp.DecompressFailed(), resp.Text())
+	}
+}
+
+func TestWithStrictDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentEncoding, r.URL.Query().Get("encoding"))
+		_, _ = w.Write([]byte("surf"))
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL + "?encoding=zstd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "surf" {
+		t.Fatalf("lenient decoding expect the body as is output %s.", resp.Text())
+	}
+
+	_, err = New(nil).Get(server.URL+"?encoding=zstd", WithStrictDecoding())
+	if !errors.Is(err, ErrUnsupportedContentEncoding) || !strings.Contains(err.Error(), "zstd") {
+		t.Fatalf("expect ErrUnsupportedContentEncoding output %v.", err)
+	}
+
+	resp, err = New(nil).Get(server.URL+"?encoding=identity", WithStrictDecoding())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "surf" {
+		t.Fatalf("identity expect the body as is output %s.", resp.Text())
+	}
+}
@@ -1,8 +1,82 @@
 package surf
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
 
 var (
-	ErrRequestDataTypeInvalid  = errors.New("request data type is not supported")
-	ErrRedirectMissingLocation = errors.New("redirect missing location header")
+	ErrRequestDataTypeInvalid         = errors.New("request data type is not supported")
+	ErrRedirectMissingLocation        = errors.New("redirect missing location header")
+	ErrHostUnresolved                 = errors.New("host could not be resolved")
+	ErrBodyRead                       = errors.New("failed to read response body")
+	ErrBodyReadTimeout                = errors.New("response body read timeout")
+	ErrDecompress                     = errors.New("failed to decompress response body")
+	ErrUnsupportedContentEncoding     = errors.New("response content encoding is not supported")
+	ErrAbsoluteURLRequired            = errors.New("absolute url is required")
+	ErrUnresolvedPathParam            = errors.New("unresolved path param")
+	ErrChecksumMismatch               = errors.New("checksum mismatch")
+	ErrChecksumAlgorithmUnsupported   = errors.New("checksum algorithm is not supported")
+	ErrJsonTypeMismatch               = errors.New("json type mismatch")
+	ErrResponseContentTypeUnsupported = errors.New("response content type is not supported")
+	ErrUnexpectedContentType          = errors.New("unexpected response content type")
+	ErrCompressionLevelInvalid        = errors.New("compression level is invalid")
+	ErrPreconditionFailed             = errors.New("precondition failed")
+	ErrTimeout                        = errors.New("request timeout")
+	ErrCanceled                       = errors.New("request canceled")
+	ErrInvalidHeaderField             = errors.New("invalid header field")
 )
+
+// encodeBodyError wraps an error raised while encoding the request body v.
+func encodeBodyError(v interface{}, err error) error {
+	return fmt.Errorf("surf: encode request body %T: %w", v, err)
+}
+
+// transportError classifies an error returned by the http.Client, wrapping DNS failures with
+// ErrHostUnresolved. The *net.DNSError stays available with errors.As.
+func transportError(err error) error {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return fmt.Errorf("%w: %w", ErrHostUnresolved, err)
+	}
+	return err
+}
+
+// contextError classifies the error of a request, wrapping timeouts, from the deadline of the context,
+// the timeout of the client or WithBodyReadTimeout, with ErrTimeout and context cancellations with ErrCanceled.
+// The original error stays matchable, e.g. with context.DeadlineExceeded.
+func contextError(err error) error {
+	if errors.Is(err, ErrTimeout) || errors.Is(err, ErrCanceled) {
+		return err
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrBodyReadTimeout),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("%w: %w", ErrCanceled, err)
+	}
+	return err
+}
+
+// BodyError reports a failure while reading a response body.
+// It matches Kind with errors.Is: ErrBodyRead when reading from the connection failed,
+// worth retrying, or ErrDecompress when the body could not be decoded, which is not.
+type BodyError struct {
+	Kind      error
+	Encoding  string
+	BytesRead int64
+	Err       error
+}
+
+func (e *BodyError) Error() string {
+	return fmt.Sprintf("%s (content-encoding %q, %d bytes read): %s", e.Kind, e.Encoding, e.BytesRead, e.Err)
+}
+
+func (e *BodyError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
@@ -0,0 +1,54 @@
+package surf
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// nestedFormValues flattens a map into bracketed form keys as parsed by PHP and Rails:
+// {"user": {"name": "a", "tags": ["x", "y"]}} becomes user[name]=a&user[tags][0]=x&user[tags][1]=y.
+// Maps with string keys and slices nest, nil values are sent empty and other values are formatted with fmt.
+func nestedFormValues(m map[string]interface{}) url.Values {
+	values := make(url.Values)
+	for key, value := range m {
+		addNestedFormValue(values, key, reflect.ValueOf(value))
+	}
+	return values
+}
+
+// addNestedFormValue adds v under key, recursing into maps and slices.
+func addNestedFormValue(values url.Values, key string, v reflect.Value) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			values.Add(key, "")
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		values.Add(key, "")
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			values.Add(key, fmt.Sprint(v.Interface()))
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			addNestedFormValue(values, key+"["+iter.Key().String()+"]", iter.Value())
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			values.Add(key, string(v.Bytes()))
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			addNestedFormValue(values, key+"["+strconv.Itoa(i)+"]", v.Index(i))
+		}
+	default:
+		values.Add(key, fmt.Sprint(v.Interface()))
+	}
+}
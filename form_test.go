@@ -0,0 +1,52 @@
+package surf
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNestedFormValues(t *testing.T) {
+	values := nestedFormValues(map[string]interface{}{
+		"name": "surf",
+		"user": map[string]interface{}{
+			"id":   1,
+			"tags": []string{"a", "b"},
+			"address": map[string]string{
+				"city": "Paris",
+			},
+		},
+		"items": []interface{}{
+			map[string]interface{}{"sku": "x", "qty": 2},
+		},
+		"empty": nil,
+	})
+
+	expect := "empty=&items%5B0%5D%5Bqty%5D=2&items%5B0%5D%5Bsku%5D=x&name=surf&user%5Baddress%5D%5Bcity%5D=Paris&user%5Bid%5D=1&user%5Btags%5D%5B0%5D=a&user%5Btags%5D%5B1%5D=b"
+	if qs := values.Encode(); qs != expect {
+		t.Fatalf("form expect %s output %s.", expect, qs)
+	}
+}
+
+func TestSurf_NestedFormBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, r.PostForm.Get("user[tags][1]")+r.PostForm.Get("user[name]"))
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Post(server.URL,
+		WithSetHeader(http.Header{"Content-Type": {defaultFormContentType}}),
+		WithBody(map[string]interface{}{"user": map[string]interface{}{"name": "surf", "tags": []string{"a", "b"}}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "bsurf" {
+		t.Fatalf("form expect bsurf output %s.", resp.Text())
+	}
+}
@@ -0,0 +1,318 @@
+package surf
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type headerOrderKey struct{}
+
+// withHeaderOrder returns a copy of ctx carrying the header order.
+func withHeaderOrder(ctx context.Context, order []string) context.Context {
+	return context.WithValue(ctx, headerOrderKey{}, order)
+}
+
+// HeaderOrderFromContext returns the header order set with WithHeaderOrder for the request context.
+// net/http always writes headers sorted by key, so a transport that wants to honor the order
+// must read it from the request context and write the headers itself, see HeaderOrderTransport.
+func HeaderOrderFromContext(ctx context.Context) []string {
+	order, _ := ctx.Value(headerOrderKey{}).([]string)
+	return order
+}
+
+// OrderedHeaderKeys returns the keys of h in the given order, keys missing from order are
+// appended sorted. Keys in order that are not set in h are skipped.
+func OrderedHeaderKeys(h http.Header, order []string) []string {
+	keys := make([]string, 0, len(h))
+	seen := make(map[string]bool, len(h))
+
+	for _, key := range order {
+		key = http.CanonicalHeaderKey(key)
+		if _, ok := h[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+
+	rest := make([]string, 0, len(h)-len(keys))
+	for key := range h {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
+
+// HeaderOrderTransport is an HTTP/1.1 transport writing request headers in the order set with
+// WithHeaderOrder, which net/http does not support as it sorts headers by key.
+// Requests without a header order are sent with Fallback (http.DefaultTransport if nil).
+//
+// Ordered requests open a dedicated connection closed with the response body and HTTP/2 is never
+// negotiated. Proxy settings, such as HTTP_PROXY or the Proxy of the Fallback transport, are ignored:
+// ordered requests always connect to the host directly. Header fields and trailers containing
+// CR, LF or other invalid characters fail the request with ErrInvalidHeaderField.
+// Use it as Config.Transport or with WithTransport.
+type HeaderOrderTransport struct {
+	// Dialer dials the connections, a dialer with the http.DefaultTransport settings is used if nil.
+	Dialer *net.Dialer
+	// TLSClientConfig is the TLS configuration for https requests, ServerName defaults to the request host.
+	TLSClientConfig *tls.Config
+	// Fallback sends requests without a header order.
+	Fallback http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *HeaderOrderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	order := HeaderOrderFromContext(req.Context())
+	if len(order) == 0 {
+		fallback := t.Fallback
+		if fallback == nil {
+			fallback = http.DefaultTransport
+		}
+		return fallback.RoundTrip(req)
+	}
+
+	conn, err := t.dial(req)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	// Abort the exchange when the context is done
+	stop := context.AfterFunc(req.Context(), func() {
+		conn.Close()
+	})
+
+	fail := func(err error) (*http.Response, error) {
+		stop()
+		conn.Close()
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+
+	if err = writeOrderedRequest(conn, req, order); err != nil {
+		return fail(err)
+	}
+
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return fail(err)
+	}
+	res.Body = &connBody{ReadCloser: res.Body, conn: conn, stop: stop}
+	return res, nil
+}
+
+// dial opens the connection for req, negotiating TLS for https.
+func (t *HeaderOrderTransport) dial(req *http.Request) (net.Conn, error) {
+	dialer := t.Dialer
+	if dialer == nil {
+		dialer = newDialer()
+	}
+
+	host := req.URL.Hostname()
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	conn, err := dialer.DialContext(req.Context(), "tcp", net.JoinHostPort(host, port))
+	if err != nil || req.URL.Scheme != "https" {
+		return conn, err
+	}
+
+	config := &tls.Config{}
+	if t.TLSClientConfig != nil {
+		config = t.TLSClientConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	config.NextProtos = []string{"http/1.1"}
+
+	tlsConn := tls.Client(conn, config)
+	if err = tlsConn.HandshakeContext(req.Context()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// writeOrderedRequest writes req as HTTP/1.1 with its headers in the given order.
+func writeOrderedRequest(w io.Writer, req *http.Request, order []string) error {
+	bw := bufio.NewWriter(w)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	header := req.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	if header.Get("Host") == "" {
+		header.Set("Host", host)
+	}
+	header.Set("Connection", "close")
+
+	hasBody := req.Body != nil && req.Body != http.NoBody
+
+	// Trailers follow the last chunk, so they require a chunked body
+	chunked := len(req.Trailer) > 0 || (hasBody && req.ContentLength <= 0)
+	if chunked {
+		header.Set("Transfer-Encoding", "chunked")
+	} else if hasBody {
+		header.Set(headerContentLength, strconv.FormatInt(req.ContentLength, 10))
+	}
+	if len(req.Trailer) > 0 {
+		keys := make([]string, 0, len(req.Trailer))
+		for key := range req.Trailer {
+			keys = append(keys, http.CanonicalHeaderKey(key))
+		}
+		sort.Strings(keys)
+		header.Set("Trailer", strings.Join(keys, ","))
+	}
+
+	if err := validateHeader(header); err != nil {
+		return err
+	}
+	if !validHeaderFieldName(req.Method) {
+		return fmt.Errorf("%w: method %q", ErrInvalidHeaderField, req.Method)
+	}
+
+	// Host leads unless its position is given explicitly
+	keys := OrderedHeaderKeys(header, append([]string{"Host"}, order...))
+	for _, key := range order {
+		if http.CanonicalHeaderKey(key) == "Host" {
+			keys = OrderedHeaderKeys(header, order)
+			break
+		}
+	}
+
+	if _, err := fmt.Fprintf(bw, "%s %s HTTP/1.1\r\n", req.Method, req.URL.RequestURI()); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		for _, value := range header[key] {
+			if _, err := fmt.Fprintf(bw, "%s: %s\r\n", key, value); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := bw.WriteString("\r\n"); err != nil {
+		return err
+	}
+
+	if hasBody {
+		defer req.Body.Close()
+	}
+
+	var bodyWriter io.Writer = bw
+	var chunkedWriter io.WriteCloser
+	if chunked {
+		chunkedWriter = httputil.NewChunkedWriter(bw)
+		bodyWriter = chunkedWriter
+	}
+	if hasBody {
+		if _, err := io.Copy(bodyWriter, req.Body); err != nil {
+			return err
+		}
+	}
+	if chunkedWriter != nil {
+		if err := chunkedWriter.Close(); err != nil {
+			return err
+		}
+		// Trailer values may be set while the body is read, they are checked once it is done
+		if err := validateHeader(req.Trailer); err != nil {
+			return err
+		}
+		for key, values := range req.Trailer {
+			for _, value := range values {
+				if _, err := fmt.Fprintf(bw, "%s: %s\r\n", http.CanonicalHeaderKey(key), value); err != nil {
+					return err
+				}
+			}
+		}
+		if _, err := bw.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// validateHeader returns an error wrapping ErrInvalidHeaderField when a field name or value
+// could split the request, as net/http rejects them.
+func validateHeader(header http.Header) error {
+	for key, values := range header {
+		if !validHeaderFieldName(key) {
+			return fmt.Errorf("%w: name %q", ErrInvalidHeaderField, key)
+		}
+		for _, value := range values {
+			if !validHeaderFieldValue(value) {
+				return fmt.Errorf("%w: value for %q", ErrInvalidHeaderField, key)
+			}
+		}
+	}
+	return nil
+}
+
+// validHeaderFieldName reports whether name is a valid token, see RFC 7230 section 3.2.6.
+func validHeaderFieldName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c >= 0x7f || c <= ' ' || strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// validHeaderFieldValue reports whether value has no control characters other than tab.
+func validHeaderFieldValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; (c < ' ' && c != '\t') || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// connBody closes the dedicated connection with the response body.
+type connBody struct {
+	io.ReadCloser
+	conn net.Conn
+	stop func() bool
+}
+
+// Close implements io.Closer.
+func (b *connBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.stop()
+	b.conn.Close()
+	return err
+}
@@ -0,0 +1,145 @@
+package surf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOrderedHeaderKeys(t *testing.T) {
+	h := http.Header{"C": {"1"}, "B": {"1"}, "A": {"1"}, "D": {"1"}}
+	keys := OrderedHeaderKeys(h, []string{"d", "b", "missing"})
+	if strings.Join(keys, ",") != "D,B,A,C" {
+		t.Fatalf("ordered keys expect D,B,A,C output %v.", keys)
+	}
+}
+
+func TestHeaderOrderTransport(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var names []string
+		r := bufio.NewReader(conn)
+		_, _ = r.ReadString('\n')
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+			name, _, _ := strings.Cut(line, ":")
+			names = append(names, name)
+		}
+		body := strings.Join(names, ",")
+		_, _ = fmt.Fprintf(conn, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\n\r\n%s", len(body), body)
+	}()
+
+	client := New(&Config{Client: &http.Client{}, Transport: &HeaderOrderTransport{}})
+	resp, err := client.Get("http://"+ln.Addr().String(),
+		WithSetHeader(http.Header{"X-B": {"1"}, "X-A": {"1"}}),
+		WithHeaderOrder([]string{"User-Agent", "X-B", "X-A", "Accept"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(resp.Text(), "Host,User-Agent,X-B,X-A,Accept,") {
+		t.Fatalf("header order unexpected %s.", resp.Text())
+	}
+}
+
+// newOrderedRequest returns a request to url carrying a header order for HeaderOrderTransport.
+func newOrderedRequest(method, url string, body io.Reader) *http.Request {
+	req, _ := http.NewRequest(method, url, body)
+	return req.WithContext(withHeaderOrder(req.Context(), []string{"User-Agent"}))
+}
+
+func TestHeaderOrderTransport_InvalidHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	for _, header := range []http.Header{
+		{"X-A": {"1\r\nX-Injected: 1"}},
+		{"X-A\r\nX-Injected": {"1"}},
+	} {
+		body := &closeCounter{Reader: strings.NewReader("surf")}
+		req := newOrderedRequest(http.MethodPost, server.URL, body)
+		req.Header = header
+
+		_, err := (&HeaderOrderTransport{}).RoundTrip(req)
+		if !errors.Is(err, ErrInvalidHeaderField) {
+			t.Fatalf("expect ErrInvalidHeaderField output %v.", err)
+		}
+		if body.closed == 0 {
+			t.Fatal("failed request expect body closed.")
+		}
+	}
+}
+
+func TestHeaderOrderTransport_ReadResponseError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = http.ReadRequest(bufio.NewReader(conn))
+		conn.Close()
+	}()
+
+	body := &closeCounter{Reader: strings.NewReader("surf")}
+	req := newOrderedRequest(http.MethodPost, "http://"+ln.Addr().String(), body)
+	if _, err := (&HeaderOrderTransport{}).RoundTrip(req); err == nil {
+		t.Fatal("closed connection expect error.")
+	}
+	if body.closed == 0 {
+		t.Fatal("failed request expect body closed.")
+	}
+}
+
+func TestHeaderOrderTransport_Trailer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = fmt.Fprintf(w, "%s|%s", body, r.Trailer.Get("Grpc-Status"))
+	}))
+	defer server.Close()
+
+	for _, body := range []io.Reader{strings.NewReader("surf"), nil} {
+		req := newOrderedRequest(http.MethodPost, server.URL, body)
+		req.Trailer = http.Header{"Grpc-Status": {"0"}}
+
+		res, err := (&HeaderOrderTransport{}).RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		expect := "|0"
+		if body != nil {
+			expect = "surf|0"
+		}
+		if string(data) != expect {
+			t.Fatalf("trailer expect %s output %s.", expect, data)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package surf
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// InFlightInfo describes a request in progress, see Surf.InFlight.
+type InFlightInfo struct {
+	Method string
+	URL    string
+	// Start is the time the request entered Surf.Request, before waiting for concurrency limits.
+	Start time.Time
+	// Attempt is the attempt being sent, starting at 1, zero while the request waits to be sent.
+	Attempt int
+}
+
+// inFlightRequest is a request tracked by the in-flight registry.
+type inFlightRequest struct {
+	method  string
+	url     string
+	start   time.Time
+	attempt atomic.Int64
+}
+
+// setAttempt records the attempt being sent, it is a no-op when tracking is disabled.
+func (r *inFlightRequest) setAttempt(n int) {
+	if r != nil {
+		r.attempt.Store(int64(n))
+	}
+}
+
+// inFlightRegistry tracks the requests in progress of a Surf instance when Config.TrackInFlight is set.
+type inFlightRegistry struct {
+	mu       sync.Mutex
+	requests map[*inFlightRequest]struct{}
+}
+
+// add registers a request, the returned function removes it.
+func (r *inFlightRegistry) add(method, url string) (*inFlightRequest, func()) {
+	req := &inFlightRequest{method: method, url: url, start: time.Now()}
+
+	r.mu.Lock()
+	if r.requests == nil {
+		r.requests = make(map[*inFlightRequest]struct{})
+	}
+	r.requests[req] = struct{}{}
+	r.mu.Unlock()
+
+	return req, func() {
+		r.mu.Lock()
+		delete(r.requests, req)
+		r.mu.Unlock()
+	}
+}
+
+// InFlight returns the requests in progress, oldest first, to diagnose stalled requests.
+// A streaming response stays in flight until it is closed.
+// It is empty unless Config.TrackInFlight is set.
+func (s *Surf) InFlight() []InFlightInfo {
+	s.inFlight.mu.Lock()
+	infos := make([]InFlightInfo, 0, len(s.inFlight.requests))
+	for req := range s.inFlight.requests {
+		infos = append(infos, InFlightInfo{
+			Method:  req.method,
+			URL:     req.url,
+			Start:   req.start,
+			Attempt: int(req.attempt.Load()),
+		})
+	}
+	s.inFlight.mu.Unlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Start.Before(infos[j].Start)
+	})
+	return infos
+}
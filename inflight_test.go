@@ -0,0 +1,40 @@
+package surf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSurf_InFlight(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+
+	client := New(&Config{TrackInFlight: true})
+	done := make(chan error)
+	go func() {
+		_, err := client.Get(server.URL + "/slow")
+		done <- err
+	}()
+
+	var infos []InFlightInfo
+	for i := 0; i < 100 && len(infos) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		infos = client.InFlight()
+	}
+	if len(infos) != 1 || infos[0].URL != server.URL+"/slow" || infos[0].Method != http.MethodGet || infos[0].Attempt != 1 {
+		t.Fatalf("in flight expect the slow request output %+v.", infos)
+	}
+
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if infos = client.InFlight(); len(infos) != 0 {
+		t.Fatalf("in flight expect empty output %+v.", infos)
+	}
+}
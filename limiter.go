@@ -0,0 +1,113 @@
+package surf
+
+import (
+	"context"
+	"sync"
+)
+
+// semaphore limits the number of concurrent holders, acquiring respects context cancellation.
+// Every request takes a single slot, so a buffered channel serves as the weighted semaphore
+// without depending on golang.org/x/sync/semaphore.
+type semaphore chan struct{}
+
+func newSemaphore(n int) semaphore {
+	return make(semaphore, n)
+}
+
+// acquire blocks until a slot is free or ctx is done.
+func (s semaphore) acquire(ctx context.Context) error {
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot previously taken by acquire.
+func (s semaphore) release() {
+	<-s
+}
+
+// concurrencyLimiter lazily builds the semaphore enforcing Config.MaxConcurrentRequests.
+// The semaphore is rebuilt when the limit changes, requests holding a slot of the previous
+// one keep it until they are done.
+type concurrencyLimiter struct {
+	mu  sync.Mutex
+	max int
+	sem semaphore
+}
+
+// acquire takes a slot when max is positive, the returned function releases it.
+func (l *concurrencyLimiter) acquire(ctx context.Context, max int) (func(), error) {
+	if max <= 0 {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	if l.sem == nil || l.max != max {
+		l.sem = newSemaphore(max)
+		l.max = max
+	}
+	sem := l.sem
+	l.mu.Unlock()
+
+	if err := sem.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return sem.release, nil
+}
+
+// hostLimiter lazily builds one semaphore per host enforcing Config.MaxConcurrentRequestsPerHost.
+// A semaphore is dropped once no request holds or waits for it, so the hosts do not accumulate,
+// and rebuilt when the limit changes, requests holding a slot of the previous one keep it.
+type hostLimiter struct {
+	mu    sync.Mutex
+	hosts map[string]*hostSemaphore
+}
+
+// hostSemaphore is the semaphore of a host with the number of requests holding or waiting for it.
+type hostSemaphore struct {
+	sem  semaphore
+	max  int
+	refs int
+}
+
+// acquire takes a slot for host when max is positive, the returned function releases it.
+func (l *hostLimiter) acquire(ctx context.Context, host string, max int) (func(), error) {
+	if max <= 0 {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	if l.hosts == nil {
+		l.hosts = make(map[string]*hostSemaphore)
+	}
+	h, ok := l.hosts[host]
+	if !ok || h.max != max {
+		h = &hostSemaphore{sem: newSemaphore(max), max: max}
+		l.hosts[host] = h
+	}
+	h.refs++
+	l.mu.Unlock()
+
+	if err := h.sem.acquire(ctx); err != nil {
+		l.done(host, h)
+		return nil, err
+	}
+	return func() {
+		h.sem.release()
+		l.done(host, h)
+	}, nil
+}
+
+// done drops the reference of a request to the semaphore of host, removing it once unused.
+func (l *hostLimiter) done(host string, h *hostSemaphore) {
+	l.mu.Lock()
+	h.refs--
+	// The semaphore may already be replaced after a limit change
+	if h.refs == 0 && l.hosts[host] == h {
+		delete(l.hosts, host)
+	}
+	l.mu.Unlock()
+}
@@ -0,0 +1,151 @@
+package surf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiter_Resize(t *testing.T) {
+	var l concurrencyLimiter
+	ctx := context.Background()
+
+	if _, err := l.acquire(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(timeout, 1); err == nil {
+		t.Fatal("full limiter expect error.")
+	}
+
+	// Raising the limit takes effect for the next requests
+	for i := 0; i < 2; i++ {
+		if _, err := l.acquire(ctx, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHostLimiter_Resize(t *testing.T) {
+	var l hostLimiter
+	ctx := context.Background()
+
+	release, err := l.acquire(ctx, "example.com", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(timeout, "example.com", 1); err == nil {
+		t.Fatal("full host limiter expect error.")
+	}
+
+	// Raising the limit takes effect for the next requests
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		r, err := l.acquire(ctx, "example.com", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		releases = append(releases, r)
+	}
+
+	release()
+	if len(l.hosts) != 1 {
+		t.Fatal("releasing a previous semaphore expect current one kept.")
+	}
+	for _, r := range releases {
+		r()
+	}
+	if len(l.hosts) != 0 {
+		t.Fatalf("idle host semaphores expect dropped output %d.", len(l.hosts))
+	}
+}
+
+// newMaxInFlightServer returns a server recording the maximum number of requests it handled concurrently.
+func newMaxInFlightServer(maxInFlight *int32) *httptest.Server {
+	var inFlight int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+}
+
+func TestSurf_MaxConcurrentRequestsPerHost(t *testing.T) {
+	var maxA, maxB int32
+	serverA, serverB := newMaxInFlightServer(&maxA), newMaxInFlightServer(&maxB)
+	defer serverA.Close()
+	defer serverB.Close()
+
+	client := New(&Config{MaxConcurrentRequestsPerHost: 2, Client: &http.Client{}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, u := range []string{serverA.URL, serverB.URL} {
+			wg.Add(1)
+			go func(u string) {
+				defer wg.Done()
+				if _, err := client.Get(u); err != nil {
+					t.Error(err)
+				}
+			}(u)
+		}
+	}
+	wg.Wait()
+
+	if maxA > 2 || maxB > 2 {
+		t.Fatalf("per host concurrency expect at most 2 output %d %d.", maxA, maxB)
+	}
+	if maxA+maxB <= 2 {
+		t.Fatalf("hosts expect limited separately output %d %d.", maxA, maxB)
+	}
+	if n := len(client.hostLimiter.hosts); n != 0 {
+		t.Fatalf("idle host semaphores expect dropped output %d.", n)
+	}
+}
+
+func TestConfig_MaxConnsPerHost(t *testing.T) {
+	var maxInFlight int32
+	server := newMaxInFlightServer(&maxInFlight)
+	defer server.Close()
+
+	client := New(&Config{MaxConnsPerHost: 1, Client: &http.Client{}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Get(server.URL); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight != 1 {
+		t.Fatalf("connections per host expect 1 output %d.", maxInFlight)
+	}
+
+	// A client supplied with the request keeps its own transport
+	requestClient := &http.Client{}
+	resp, err := client.Request(&RequestConfig{Url: server.URL, Client: requestClient})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.config.Client != requestClient {
+		t.Fatal("request client expect used as is.")
+	}
+}
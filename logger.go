@@ -0,0 +1,20 @@
+package surf
+
+import (
+	"context"
+)
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying l, used as the Logger of the requests sent
+// with this context unless they set one with WithLogger. It lets a server handler pass its
+// request-scoped logger, with fields such as the request ID, down to the requests it makes.
+func ContextWithLogger(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFromContext returns the Logger set with ContextWithLogger, nil if none.
+func LoggerFromContext(ctx context.Context) Logger {
+	l, _ := ctx.Value(loggerKey{}).(Logger)
+	return l
+}
@@ -5,14 +5,21 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime/multipart"
+	"net/textproto"
 	"os"
+	"path"
+	"strings"
 )
 
 type multipartFile struct {
 	data   *bytes.Buffer
 	writer *multipart.Writer
 	errors []error // Collect errors
+
+	jsonMarshal func(v interface{}) ([]byte, error)
+	gzip        bool
 }
 
 // NewMultipartFile creates a multipart file writer with optional initial capacity
@@ -72,6 +79,19 @@ func (m *multipartFile) AddFileFromPath(field, path string) {
 	m.AddFileReader(field, file.Name(), file)
 }
 
+// AddFileFromFS opens the file name from fsys, e.g. an embed.FS, and adds it to the writer
+// under its base name.
+func (m *multipartFile) AddFileFromFS(field string, fsys fs.FS, name string) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		m.saveError(err)
+		return
+	}
+	defer file.Close()
+
+	m.AddFileReader(field, path.Base(name), file)
+}
+
 // AddField add field to the writer
 func (m *multipartFile) AddField(field, filename string) {
 	err := m.writer.WriteField(field, filename)
@@ -80,6 +100,51 @@ func (m *multipartFile) AddField(field, filename string) {
 	}
 }
 
+// AddPart adds a part with the given content type, e.g. a JSON or XML document alongside the files.
+// The filename is optional and omitted from the Content-Disposition when empty.
+func (m *multipartFile) AddPart(field, filename, contentType string, data []byte) {
+	disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(field))
+	if filename != "" {
+		disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(filename))
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set(headerContentDisposition, disposition)
+	if contentType != "" {
+		h.Set(headerContentType, contentType)
+	}
+	w, err := m.writer.CreatePart(h)
+	if err != nil {
+		m.saveError(err)
+		return
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		m.saveError(err)
+	}
+}
+
+// AddJSONPart marshals v and adds it as a part with an application/json content type,
+// e.g. the metadata part of a "metadata + file" upload. It uses the marshaler set with
+// SetJSONMarshal, or the default JSON codec.
+func (m *multipartFile) AddJSONPart(field string, v interface{}) {
+	marshal := m.jsonMarshal
+	if marshal == nil {
+		marshal, _ = defaultJSONCodec()
+	}
+	data, err := marshal(v)
+	if err != nil {
+		m.saveError(fmt.Errorf("multipartFile field:%s marshal json: %w", field, err))
+		return
+	}
+	m.AddPart(field, "", defaultJsonContentType, data)
+}
+
+// AddJSONField is an alias of AddJSONPart.
+func (m *multipartFile) AddJSONField(field string, v interface{}) {
+	m.AddJSONPart(field, v)
+}
+
 // AddFields adds fields to the writer
 func (m *multipartFile) AddFields(fields map[string]string) {
 	for k, v := range fields {
@@ -124,6 +189,17 @@ func (m *multipartFile) SetCustomBuffer(buffer *bytes.Buffer) {
 	m.data = buffer
 }
 
+// SetJSONMarshal sets the marshaler used by AddJSONPart, nil restores the default JSON codec
+func (m *multipartFile) SetJSONMarshal(marshal func(v interface{}) ([]byte, error)) {
+	m.jsonMarshal = marshal
+}
+
+// SetGzip compresses the multipart body with gzip when it is sent, with a Content-Encoding: gzip header.
+// Only use it with servers that accept compressed request bodies.
+func (m *multipartFile) SetGzip(enabled bool) {
+	m.gzip = enabled
+}
+
 // SetFileWriter sets a file as the writer for large files
 func (m *multipartFile) SetFileWriter(file *os.File) {
 	m.writer = multipart.NewWriter(file)
@@ -132,3 +208,10 @@ func (m *multipartFile) SetFileWriter(file *os.File) {
 func (m *multipartFile) saveError(err error) {
 	m.errors = append(m.errors, err)
 }
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes a multipart parameter value the same way mime/multipart does.
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,187 @@
+package surf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMultipartFile_AddJSONField(t *testing.T) {
+	m := NewMultipartFile(0)
+	m.AddJSONField("metadata", map[string]string{"name": "a.txt"})
+	m.AddFile("file", "a.txt", []byte("surf"))
+
+	data, err := m.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, params, _ := mime.ParseMediaType(m.FormDataContentType())
+	reader := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+
+	part, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.FormName() != "metadata" || part.Header.Get(headerContentType) != defaultJsonContentType {
+		t.Fatalf("json part unexpected %s %s.", part.FormName(), part.Header.Get(headerContentType))
+	}
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(part)
+	if buf.String() != `{"name":"a.txt"}` {
+		t.Fatalf("json part body unexpected %s.", buf.String())
+	}
+}
+
+func TestMultipartFile_AddJSONPart(t *testing.T) {
+	m := NewMultipartFile(0)
+	m.SetJSONMarshal(func(v interface{}) ([]byte, error) {
+		return []byte(`{"custom":true}`), nil
+	})
+	m.AddJSONPart("metadata", nil)
+	m.AddPart("doc", "doc.xml", "application/xml", []byte("<doc/>"))
+
+	data, err := m.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, params, _ := mime.ParseMediaType(m.FormDataContentType())
+	reader := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+
+	part, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(part)
+	if buf.String() != `{"custom":true}` {
+		t.Fatalf("json part expect custom marshaler output %s.", buf.String())
+	}
+
+	part, err = reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.FormName() != "doc" || part.FileName() != "doc.xml" || part.Header.Get(headerContentType) != "application/xml" {
+		t.Fatalf("xml part unexpected %s %s %s.", part.FormName(), part.FileName(), part.Header.Get(headerContentType))
+	}
+}
+
+func TestMultipartFile_SetGzip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(zr)
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = io.Copy(w, file)
+	}))
+	defer server.Close()
+
+	m := NewMultipartFile(0)
+	m.AddFile("file", "a.txt", []byte("surf"))
+	m.SetGzip(true)
+
+	resp, err := New(nil).Upload(server.URL, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != http.StatusOK || resp.Text() != "surf" {
+		t.Fatalf("gzip upload expect surf output %d %s.", resp.Status(), resp.Text())
+	}
+}
+
+func TestMultipartFile_SetGzipError(t *testing.T) {
+	m := NewMultipartFile(0)
+	m.AddFile("file", "a.txt", []byte("surf"))
+	m.SetGzip(true)
+
+	config := &RequestConfig{Body: m, CompressionLevel: 42}
+	if _, err := config.getRequestBody(); err == nil {
+		t.Fatal("invalid compression level expect error.")
+	}
+	if config.Header.Get(headerContentEncoding) != "" {
+		t.Fatalf("failed gzip expect no content encoding output %s.", config.Header.Get(headerContentEncoding))
+	}
+}
+
+func TestWithCompressionLevel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strconv.FormatInt(r.ContentLength, 10)))
+	}))
+	defer server.Close()
+
+	upload := func(level int) (int, error) {
+		m := NewMultipartFile(0)
+		m.AddFile("file", "a.txt", []byte(strings.Repeat("surf", 4096)))
+		m.SetGzip(true)
+		resp, err := New(nil).Upload(server.URL, m, WithCompressionLevel(level))
+		if err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(resp.Text())
+	}
+
+	fast, err := upload(gzip.HuffmanOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, err := upload(gzip.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best >= fast {
+		t.Fatalf("best compression expect smaller than huffman only output %d >= %d.", best, fast)
+	}
+
+	if _, err := upload(10); !errors.Is(err, ErrCompressionLevelInvalid) {
+		t.Fatalf("expect ErrCompressionLevelInvalid output %v.", err)
+	}
+}
+
+func TestMultipartFile_AddFileFromFS(t *testing.T) {
+	fsys := fstest.MapFS{"assets/logo.txt": {Data: []byte("surf")}}
+
+	m := NewMultipartFile(0)
+	m.AddFileFromFS("file", fsys, "assets/logo.txt")
+	data, err := m.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, params, _ := mime.ParseMediaType(m.FormDataContentType())
+	part, err := multipart.NewReader(bytes.NewReader(data), params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(part)
+	if part.FormName() != "file" || part.FileName() != "logo.txt" || string(body) != "surf" {
+		t.Fatalf("file part unexpected %s %s %s.", part.FormName(), part.FileName(), body)
+	}
+
+	m = NewMultipartFile(0)
+	m.AddFileFromFS("file", fsys, "missing.txt")
+	if _, err := m.Bytes(); err == nil {
+		t.Fatal("missing file expect error.")
+	}
+}
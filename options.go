@@ -1,9 +1,15 @@
 package surf
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -13,12 +19,118 @@ type WithRequestConfig func(c *RequestConfig)
 type WithRequestConfigChain []WithRequestConfig
 
 // WithBody sets the request body in the request configuration.
+// A body implementing io.Closer is closed by Surf once the request is done, even on error,
+// the same as http.Client.Do, so the caller must not close or reuse it.
 func WithBody(body interface{}) WithRequestConfig {
 	return func(c *RequestConfig) {
 		c.Body = body
 	}
 }
 
+// WithJSONBody sets v as the request body, marshaled with the request JSONMarshal (Config.JSONMarshal,
+// then the default JSON codec, if unset) when the request is built, and sets the JSON content type.
+// Interceptors can still edit v, a marshal error is returned by the request before anything is sent.
+func WithJSONBody(v interface{}) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.Body = JSON(v)
+		c.SetHeader(headerContentType, defaultJsonContentType)
+	}
+}
+
+// WithStreamJSON encodes a struct or map body set with WithBody as JSON with encoding/json while it
+// is sent, instead of marshaling it into memory first, to bound the memory of large uploads.
+// It sets the JSON content type. The configured JSONMarshal is not used and the body is sent
+// chunked; since it cannot be replayed the request is not retried.
+func WithStreamJSON() WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.StreamJSON = true
+		c.SetHeader(headerContentType, defaultJsonContentType)
+	}
+}
+
+// WithCompressionLevel sets the gzip level compressing the request body, such as a multipart body
+// sent with multipartFile.SetGzip, from gzip.HuffmanOnly (-2) and gzip.BestSpeed (1), fastest,
+// to gzip.BestCompression (9), smallest. Zero, or gzip.DefaultCompression (-1), uses the default level 6.
+// A level out of range fails the request with ErrCompressionLevelInvalid.
+func WithCompressionLevel(level int) WithRequestConfig {
+	return func(c *RequestConfig) {
+		if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			c.err = fmt.Errorf("%w: %d", ErrCompressionLevelInvalid, level)
+			return
+		}
+		c.CompressionLevel = level
+	}
+}
+
+// WithStream leaves the response body unread so it can be consumed while it is received,
+// for example with Response.NDJSON. The body is still decoded according to its Content-Encoding.
+// Body, Text and the other buffered accessors are empty and Result is not decoded.
+// The caller must call Response.Close to release the connection.
+func WithStream() WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.Stream = true
+	}
+}
+
+// WithResponseBodyConsumed(false) leaves the body of Response.OriginalResponse unread, still encoded
+// as sent by the server, so the untouched *http.Response can be handed on, e.g. through a reverse proxy.
+// Body, Text and the other buffered accessors are empty and Result is not decoded.
+// The caller must close OriginalResponse().Body or call Response.Close to release the connection.
+func WithResponseBodyConsumed(consumed bool) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.RawResponse = !consumed
+	}
+}
+
+// WithStrictDecoding fails the request with ErrUnsupportedContentEncoding when the response uses a
+// Content-Encoding without registered decoder, see RegisterDecoder, instead of returning the body
+// still encoded, so compressed bytes are never parsed as JSON by accident.
+func WithStrictDecoding() WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.StrictDecoding = true
+	}
+}
+
+// WithDecompressFallback returns the raw body as received when it cannot be decoded according to its
+// Content-Encoding, instead of failing the request, for servers mislabeling plain bodies as gzip.
+// Response.DecompressFailed reports the fallback, which is also logged to the Logger as a warning.
+// The body is read into memory before being decoded. It does not apply to streaming responses.
+func WithDecompressFallback() WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.DecompressFallback = true
+	}
+}
+
+// WithResponseBodyBuffer reads the response body into a *bytes.Buffer taken from pool instead of
+// allocating a new slice, to reduce allocations of high-throughput clients. A pool whose New
+// returns nil or another type is completed with a new buffer.
+//
+// The buffer is owned by the Response until Response.Release puts it back into the pool. Body,
+// Text and the other accessors share its memory: the caller must not use them, nor keep slices
+// returned by Body, after calling Release. The Result is decoded before the request returns.
+// Not calling Release is safe, the buffer is then garbage collected.
+func WithResponseBodyBuffer(pool *sync.Pool) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.BodyBufferPool = pool
+	}
+}
+
+// WithResult sets the target decoded from the body of a successful (2xx) response.
+func WithResult(v interface{}) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.Result = v
+	}
+}
+
+// WithError sets the target decoded from the body of a non-2xx response.
+// A body of an unsupported content type, such as an HTML error page, is left undecoded
+// and the response is returned as is.
+func WithError(v interface{}) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.ErrorResult = v
+	}
+}
+
 // WithBaseURL sets the BaseURL parameters in the request configuration.
 func WithBaseURL(url string) WithRequestConfig {
 	return func(c *RequestConfig) {
@@ -26,6 +138,13 @@ func WithBaseURL(url string) WithRequestConfig {
 	}
 }
 
+// WithBaseURLPath sets the path prefix joined between BaseURL and a relative Url in the request configuration.
+func WithBaseURLPath(path string) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.BasePath = path
+	}
+}
+
 // WithHeader sets the request header in the request configuration.
 func WithHeader(header http.Header) WithRequestConfig {
 	return func(c *RequestConfig) {
@@ -33,6 +152,14 @@ func WithHeader(header http.Header) WithRequestConfig {
 	}
 }
 
+// WithTrailer sets the trailers sent after the request body, e.g. for streaming upload protocols.
+// The body is sent with chunked encoding since trailers cannot follow a body of known length.
+func WithTrailer(trailer http.Header) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.Trailer = trailer
+	}
+}
+
 // WithQuery sets the query parameters in the request configuration.
 func WithQuery(values url.Values) WithRequestConfig {
 	return func(c *RequestConfig) {
@@ -40,6 +167,60 @@ func WithQuery(values url.Values) WithRequestConfig {
 	}
 }
 
+// WithQueryMap sets the query parameters from a map in the request configuration,
+// replacing the values of the same keys and keeping the other parameters.
+func WithQueryMap(values map[string]string) WithRequestConfig {
+	return func(c *RequestConfig) {
+		for key, value := range values {
+			c.SetQuery(key, value)
+		}
+	}
+}
+
+// WithQueryMapSlice adds the query parameters of a multi-value map in the request configuration,
+// appending to the values already set for the same keys.
+func WithQueryMapSlice(values map[string][]string) WithRequestConfig {
+	return func(c *RequestConfig) {
+		if c.Query == nil {
+			c.Query = make(url.Values)
+		}
+		for key, vals := range values {
+			c.Query[key] = append(c.Query[key], vals...)
+		}
+	}
+}
+
+// WithAllowUnresolvedParams sends the request even when :name path segments are left after
+// substituting Params, for APIs whose paths legitimately contain them. By default such a
+// request fails with ErrUnresolvedPathParam.
+func WithAllowUnresolvedParams() WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.AllowUnresolvedParams = true
+	}
+}
+
+// WithQueryFromStruct adds the exported fields of the struct v to the query parameters.
+// Fields are named by the `surf:"name"` tag, or by the field name, and skipped with `surf:"-"`;
+// the omitempty option skips zero values. Nil pointers are skipped, slices add one value per
+// element and the fields of embedded structs are promoted. time.Time is formatted with the
+// layout option, `surf:"since,layout:2006-01-02"`, defaulting to RFC 3339, and types
+// implementing encoding.TextMarshaler encode themselves.
+func WithQueryFromStruct(v interface{}) WithRequestConfig {
+	return func(c *RequestConfig) {
+		values, err := structToQuery(v)
+		if err != nil {
+			c.err = err
+			return
+		}
+		if c.Query == nil {
+			c.Query = make(url.Values)
+		}
+		for key, vals := range values {
+			c.Query[key] = append(c.Query[key], vals...)
+		}
+	}
+}
+
 // WithParams sets the parameters in the request configuration.
 func WithParams(params map[string]string) WithRequestConfig {
 	return func(c *RequestConfig) {
@@ -69,6 +250,91 @@ func WithTimeoutContext(ctx context.Context, timeout time.Duration) WithRequestC
 	}
 }
 
+// WithRequestDelay waits d before sending the request, or until the context is done, for polite
+// scraping without a rate limiter. The wait happens once the concurrency limits are acquired, so
+// with MaxConcurrentRequests set to 1 requests are spaced by at least d. Retries are not delayed.
+func WithRequestDelay(d time.Duration) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.Delay = d
+	}
+}
+
+// WithBodyReadTimeout limits the time to read the response body once the headers are received,
+// failing the request with ErrBodyReadTimeout when a server trickles the body.
+// For streaming responses it bounds the time until the body is closed.
+func WithBodyReadTimeout(d time.Duration) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.BodyReadTimeout = d
+	}
+}
+
+// WithTransport sets the transport in the request configuration, keeping the other client settings.
+func WithTransport(rt http.RoundTripper) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.Transport = rt
+	}
+}
+
+// WithLocalAddr binds the outgoing connections of the request to the given local address.
+// The request uses a clone of the client transport, so the shared transport is left untouched.
+func WithLocalAddr(addr net.Addr) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.addTransportModifier(func(t *http.Transport) {
+			dialer := newDialer()
+			dialer.LocalAddr = addr
+			t.DialContext = dialer.DialContext
+		})
+	}
+}
+
+// WithExpect100Timeout sends the request with "Expect: 100-continue" so the server can reject it
+// before the body is uploaded, and sends the body anyway when no 100 Continue arrives within d,
+// one second when d is not positive. It has no effect on requests without a body.
+// The wait is reported in Performance.ContinueWait.
+func WithExpect100Timeout(d time.Duration) WithRequestConfig {
+	if d <= 0 {
+		d = time.Second
+	}
+	return func(c *RequestConfig) {
+		c.SetHeader(headerExpect, "100-continue")
+		c.addTransportModifier(func(t *http.Transport) {
+			t.ExpectContinueTimeout = d
+		})
+	}
+}
+
+// WithProxyFunc selects the proxy of the request with fn, see http.Transport.Proxy, e.g. to rotate
+// through a proxy pool. A nil URL sends the request directly and http.ProxyURL wraps a static proxy.
+// The request uses a clone of the client transport, so the shared transport is left untouched.
+func WithProxyFunc(fn func(*http.Request) (*url.URL, error)) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.addTransportModifier(func(t *http.Transport) {
+			t.Proxy = fn
+		})
+	}
+}
+
+// WithHostResolver overrides DNS resolution for the given hosts (host → IP) in the request configuration.
+// Only the dialed address is rewritten, TLS server name and certificate verification still use the original host.
+func WithHostResolver(hosts map[string]string) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.addTransportModifier(func(t *http.Transport) {
+			dial := t.DialContext
+			if dial == nil {
+				dial = newDialer().DialContext
+			}
+			t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if host, port, err := net.SplitHostPort(addr); err == nil {
+					if ip, ok := hosts[host]; ok {
+						addr = net.JoinHostPort(ip, port)
+					}
+				}
+				return dial(ctx, network, addr)
+			}
+		})
+	}
+}
+
 // WithSetQuery adds a query parameter in the request configuration.
 func WithSetQuery(key, value string) WithRequestConfig {
 	return func(c *RequestConfig) {
@@ -94,6 +360,63 @@ func WithSetHeader(headers http.Header) WithRequestConfig {
 	}
 }
 
+// WithAcceptEncoding sets the Accept-Encoding header in the request configuration,
+// overriding the default built from the registered decoders. Use "identity" to request an uncompressed body.
+func WithAcceptEncoding(enc string) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.SetHeader(headerAcceptEncoding, enc)
+	}
+}
+
+// WithHeaderOrder sets the order in which headers are written in the request configuration.
+// Headers not listed are written after the listed ones, sorted. The order is carried on the request
+// context and needs a transport that honors it such as HeaderOrderTransport, the default
+// net/http transport ignores it.
+func WithHeaderOrder(order []string) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.HeaderOrder = order
+	}
+}
+
+// WithAcceptLanguage sets the Accept-Language header in the request configuration from language tags
+// in order of preference, weighting each following tag with a lower q-value:
+// WithAcceptLanguage("en-US", "en", "fr") sends "en-US,en;q=0.9,fr;q=0.8".
+// A tag that already carries a q-value, or a single preformatted header value, is sent as is.
+func WithAcceptLanguage(tags ...string) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.SetHeader(headerAcceptLanguage, acceptLanguage(tags))
+	}
+}
+
+// WithReferer sets the Referer header in the request configuration.
+// The URL must be absolute, its fragment and user info are not sent.
+func WithReferer(referer string) WithRequestConfig {
+	return func(c *RequestConfig) {
+		u, err := parseAbsoluteURL(referer)
+		if err != nil {
+			c.err = fmt.Errorf("surf: referer: %w", err)
+			return
+		}
+		u.User = nil
+		u.Fragment = ""
+		u.RawFragment = ""
+		c.SetHeader(headerReferer, u.String())
+	}
+}
+
+// WithOrigin sets the Origin header in the request configuration.
+// The URL must be absolute, only its scheme and host are sent.
+func WithOrigin(origin string) WithRequestConfig {
+	return func(c *RequestConfig) {
+		u, err := parseAbsoluteURL(origin)
+		if err != nil {
+			c.err = fmt.Errorf("surf: origin: %w", err)
+			return
+		}
+		c.SetHeader(headerOrigin, u.Scheme+"://"+u.Host)
+	}
+}
+
 // WithSetCookie adds a cookie in the request configuration.
 func WithSetCookie(cookie *http.Cookie) WithRequestConfig {
 	return func(c *RequestConfig) {
@@ -101,6 +424,30 @@ func WithSetCookie(cookie *http.Cookie) WithRequestConfig {
 	}
 }
 
+// WithRequestID enables request ID propagation using the X-Request-ID header.
+func WithRequestID() WithRequestConfig {
+	return func(c *RequestConfig) {
+		if c.RequestIDHeader == "" {
+			c.RequestIDHeader = headerRequestID
+		}
+	}
+}
+
+// WithRequestIDHeader enables request ID propagation using the given header name.
+func WithRequestIDHeader(name string) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.RequestIDHeader = http.CanonicalHeaderKey(name)
+	}
+}
+
+// WithMetadata sets a metadata value in the request configuration, readable by interceptors
+// and from Response.Config.
+func WithMetadata(key string, value interface{}) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.SetMetadata(key, value)
+	}
+}
+
 // WithRequestInterceptor append RequestInterceptor in the request configuration.
 func WithRequestInterceptor(handler RequestInterceptor) WithRequestConfig {
 	return func(c *RequestConfig) {
@@ -115,11 +462,216 @@ func WithResponseInterceptor(handler ResponseInterceptor) WithRequestConfig {
 	}
 }
 
+// WithRequestModifier appends a RequestModifier in the request configuration, run on the raw http.Request.
+//
+// With RunOnce, the default, it runs once before the first attempt, after headers, cookies and body are set.
+// Retries send a copy of the modified request and redirects keep its headers, other changes are not carried
+// over to redirects. Use it for one-time setup.
+//
+// With RunAlways it runs on a copy of every request handed to the transport: the first attempt, each retry
+// and each redirect hop, whether followed by the http.Client or by Surf. Use it for request signing.
+func WithRequestModifier(fn RequestModifier, run ...ModifierRun) WithRequestConfig {
+	return func(c *RequestConfig) {
+		if len(run) > 0 && run[0] == RunAlways {
+			c.AttemptModifiers = append(c.AttemptModifiers, fn)
+			return
+		}
+		c.RequestModifiers = append(c.RequestModifiers, fn)
+	}
+}
+
+// WithAutoReferer sends the URL of the last response received by the Surf instance as the Referer
+// of the request and remembers the URL of its response for the next one, see Config.AutoReferer.
+// Register it with Surf.SetDefaultOptions to chain the Referer across a whole scraping session.
+// Concurrent requests share the last URL, the chain only follows sequential requests.
+func WithAutoReferer() WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.AutoReferer = true
+	}
+}
+
+// WithConnectionClose sends the request with "Connection: close" so its connection is not reused.
+// Only this request is affected, including its retries and redirect hops, keep-alive stays enabled
+// on the shared transport.
+func WithConnectionClose() WithRequestConfig {
+	return WithRequestModifier(func(req *http.Request) {
+		req.Close = true
+	}, RunAlways)
+}
+
+// WithDeadlineHeader propagates the remaining time of the request deadline, from the context or
+// the timeout, to the header in milliseconds so downstream services can shed work that would
+// finish too late. It is updated on every attempt. The header defaults to X-Request-Timeout
+// and is not sent without a deadline.
+func WithDeadlineHeader(header string) WithRequestConfig {
+	header = defaultValue(header, headerRequestTimeout)
+	return WithRequestModifier(func(req *http.Request) {
+		deadline, ok := req.Context().Deadline()
+		if !ok {
+			return
+		}
+		remaining := time.Until(deadline).Milliseconds()
+		if remaining < 0 {
+			remaining = 0
+		}
+		req.Header.Set(header, strconv.FormatInt(remaining, 10))
+	}, RunAlways)
+}
+
+// WithLogger sets the Logger of the request, receiving its debug output and the logs of options
+// such as WithSlowRequestLog, instead of the Logger of the context or Config.Logger.
+// Interceptors can log through it with RequestConfig.Logger.
+func WithLogger(l Logger) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.Logger = l
+	}
+}
+
+// WithSlowRequestLog logs the method, URL and timings of a request whose Performance.TotalTime
+// exceeds the threshold to the configured Logger. Faster requests are not logged.
+func WithSlowRequestLog(threshold time.Duration) WithRequestConfig {
+	return WithResponseInterceptor(func(resp *Response) error {
+		perf := resp.Performance
+		if perf == nil || perf.TotalTime <= threshold {
+			return nil
+		}
+		req := resp.Request()
+		resp.config.Logger.Printf("WARN: Slow request %s %s took %s (threshold %s, response %s, attempts %d)\n",
+			req.Method, req.URL, perf.TotalTime, threshold, perf.ResponseTime, perf.Attempts)
+		return nil
+	})
+}
+
+// WithRetry enables automatic retries in the request configuration, retrying up to maxRetries
+// times after the first attempt. Only idempotent methods are retried, see RetryConfig.
+func WithRetry(maxRetries int) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.retryConfig().MaxRetries = maxRetries
+	}
+}
+
+// WithRetryBudget caps the total time spent retrying, measured from the first attempt: no retry is
+// made once the elapsed time plus the backoff before it would exceed d, even if retries remain.
+// The last response or error is returned. It bounds the latency of slow responses and long backoffs.
+func WithRetryBudget(d time.Duration) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.retryConfig().Budget = d
+	}
+}
+
+// WithRetryCondition sets the RetryCondition deciding whether an attempt is retried.
+func WithRetryCondition(fn RetryCondition) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.retryConfig().Condition = fn
+	}
+}
+
+// WithRetryOnStatus retries responses with one of the given status codes, e.g. 502, 503 and 504,
+// instead of the statuses retried by DefaultRetryCondition. Errors are retried as by DefaultRetryCondition.
+// It replaces the RetryCondition and is used together with WithRetry, see RetryOnStatus.
+func WithRetryOnStatus(codes ...int) WithRequestConfig {
+	return WithRetryCondition(RetryOnStatus(codes...))
+}
+
+// WithRetryBackoff sets the RetryBackoff returning the delay before a retry.
+func WithRetryBackoff(fn RetryBackoff) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.retryConfig().Backoff = fn
+	}
+}
+
+// WithRetryNonIdempotent allows retrying non-idempotent methods such as POST and PATCH.
+// Only use it when the server tolerates the request being processed more than once.
+func WithRetryNonIdempotent() WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.retryConfig().AllowNonIdempotent = true
+	}
+}
+
+// WithFailFastOnDNS stops retrying when the host does not exist, since retrying a misspelled host
+// only wastes attempts. Temporary DNS failures are still retried. DNS errors match ErrHostUnresolved.
+func WithFailFastOnDNS() WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.retryConfig().FailFastOnDNS = true
+	}
+}
+
+// WithResponseValidator appends a ResponseValidator in the request configuration,
+// run after the response interceptors. A non-nil error is returned from the request.
+func WithResponseValidator(fn ResponseValidator) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.ResponseValidators = append(c.ResponseValidators, fn)
+	}
+}
+
+// WithResultValidator appends a ResultValidator in the request configuration, run on the Result
+// decoded from a successful response, e.g. to check required fields or ranges. A struct-tag
+// validator plugs in directly, such as WithResultValidator(validate.Struct) with go-playground/validator.
+// It does not run for streaming responses or without a Result.
+func WithResultValidator(fn ResultValidator) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.ResultValidators = append(c.ResultValidators, fn)
+	}
+}
+
+// WithExpectedChecksum verifies the response body against the hex encoded checksum computed with
+// the given algorithm (md5, sha1, sha256 or sha512), failing the request with ErrChecksumMismatch.
+// The body of a streaming or raw response is hashed while it is read, the mismatch is then returned
+// by the Read reaching the end of the body. A raw response is hashed as received, still encoded.
+func WithExpectedChecksum(algo, expected string) WithRequestConfig {
+	return WithResponseValidator(func(resp *Response) error {
+		h, ok := newChecksumHash(algo)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrChecksumAlgorithmUnsupported, algo)
+		}
+		if resp.rawBody != nil {
+			body := &checksumBody{ReadCloser: resp.rawBody, hash: h, algo: algo, expected: expected}
+			if resp.originalResponse.Body == resp.rawBody {
+				resp.originalResponse.Body = body
+			}
+			resp.rawBody = body
+			return nil
+		}
+		h.Write(resp.body)
+		return checksumMismatch(algo, expected, hex.EncodeToString(h.Sum(nil)))
+	})
+}
+
+// WithIfMatch sends the If-Match header so that a PUT or DELETE only applies when the resource still has
+// the given entity tag, as returned in its ETag header quotes included, preventing lost updates.
+// A 412 Precondition Failed response fails the request with ErrPreconditionFailed.
+func WithIfMatch(etag string) WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.SetHeader(headerIfMatch, etag)
+		WithResponseValidator(func(resp *Response) error {
+			if resp.Status() == http.StatusPreconditionFailed {
+				return fmt.Errorf("%w: if-match %s", ErrPreconditionFailed, etag)
+			}
+			return nil
+		})(c)
+	}
+}
+
+// WithExpectJSON sets the Accept header to JSON and fails the request with ErrUnexpectedContentType
+// when the response Content-Type is not JSON, such as an HTML error page. 204 responses are accepted.
+func WithExpectJSON() WithRequestConfig {
+	return func(c *RequestConfig) {
+		c.SetHeader(headerAccept, "application/json")
+		WithResponseValidator(func(resp *Response) error {
+			contentType := resp.Headers().Get(headerContentType)
+			if resp.Status() == http.StatusNoContent || regJsonHeader.MatchString(contentType) {
+				return nil
+			}
+			return fmt.Errorf("%w: expected json, got %q", ErrUnexpectedContentType, contentType)
+		})(c)
+	}
+}
+
 // combineRequestConfig combines multiple request configurations into a single configuration.
-func combineRequestConfig(args ...WithRequestConfig) RequestConfig {
-	config := RequestConfig{}
+func combineRequestConfig(args ...WithRequestConfig) *RequestConfig {
+	config := &RequestConfig{}
 	for _, arg := range args {
-		arg(&config)
+		arg(config)
 	}
 	return config
 }
@@ -21,8 +21,13 @@ type Performance struct {
 	TLSHandshake time.Duration
 
 	// ServerTime is a duration that server took to respond first byte.
+	// With Expect: 100-continue the first byte is the one of the 100 Continue response.
 	ServerTime time.Duration
 
+	// ContinueWait is a duration that the request waited for 100 Continue before sending the body,
+	// zero when the server did not send one, see WithExpect100Timeout.
+	ContinueWait time.Duration
+
 	// ResponseTime is a duration since first response byte from server to
 	ResponseTime time.Duration
 
@@ -37,6 +42,12 @@ type Performance struct {
 
 	// ConnIdleTime is a duration how long the connection was previously
 	ConnIdleTime time.Duration
+
+	// Attempts is the number of attempts made, 1 when the request was not retried.
+	Attempts int
+
+	// TotalElapsed is the duration of all attempts including retry backoff.
+	TotalElapsed time.Duration
 }
 
 func (p *Performance) record() {
@@ -67,6 +78,10 @@ func (p *Performance) record() {
 		p.ConnTime = ct.gotConn.Sub(ct.getConn)
 	}
 
+	if !ct.wait100Continue.IsZero() && !ct.got100Continue.IsZero() {
+		p.ContinueWait = ct.got100Continue.Sub(ct.wait100Continue)
+	}
+
 	// Only calculate on successful connections
 	if !ct.gotFirstResponseByte.IsZero() {
 		p.ResponseTime = ct.endTime.Sub(ct.gotFirstResponseByte)
@@ -0,0 +1,182 @@
+package surf
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// queryTag is a parsed `surf:"name,omitempty,layout:2006-01-02"` struct tag.
+type queryTag struct {
+	name      string
+	omitEmpty bool
+	layout    string
+}
+
+func parseQueryTag(field reflect.StructField) (queryTag, bool) {
+	tag, ok := field.Tag.Lookup("surf")
+	if tag == "-" {
+		return queryTag{}, false
+	}
+
+	parts := strings.Split(tag, ",")
+	qt := queryTag{name: parts[0], layout: time.RFC3339}
+	for _, opt := range parts[1:] {
+		switch {
+		case opt == "omitempty":
+			qt.omitEmpty = true
+		case strings.HasPrefix(opt, "layout:"):
+			qt.layout = strings.TrimPrefix(opt, "layout:")
+		}
+	}
+	if !ok || qt.name == "" {
+		qt.name = field.Name
+	}
+	return qt, true
+}
+
+// structToQuery encodes the exported fields of a struct, or a pointer to one, as query values.
+// See WithQueryFromStruct for the supported field types and tag options.
+func structToQuery(v interface{}) (url.Values, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("surf: query from %T: %w", v, ErrRequestDataTypeInvalid)
+	}
+
+	values := make(url.Values)
+	if err := encodeQueryStruct(values, rv); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func encodeQueryStruct(values url.Values, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		tag, ok := parseQueryTag(field)
+		if !ok {
+			continue
+		}
+
+		fv := rv.Field(i)
+		for fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Pointer {
+			continue
+		}
+
+		// Fields of an embedded struct are promoted, unless it is named by the tag or encodes itself
+		if field.Anonymous && field.Tag.Get("surf") == "" && fv.Kind() == reflect.Struct && !isQueryScalar(fv) {
+			if err := encodeQueryStruct(values, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if tag.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if err := encodeQueryValue(values, tag, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isQueryScalar reports whether v is encoded as a single value rather than as a struct.
+func isQueryScalar(v reflect.Value) bool {
+	return v.Type() == timeType || v.Type().Implements(textMarshalerType) ||
+		reflect.PointerTo(v.Type()).Implements(textMarshalerType)
+}
+
+func encodeQueryValue(values url.Values, tag queryTag, v reflect.Value) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8) || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeQueryValue(values, tag, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s, err := formatQueryValue(tag, v)
+	if err != nil {
+		return fmt.Errorf("surf: query field %s: %w", tag.name, err)
+	}
+	values.Add(tag.name, s)
+	return nil
+}
+
+func formatQueryValue(tag queryTag, v reflect.Value) (string, error) {
+	if v.CanInterface() {
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).Format(tag.layout), nil
+		}
+		if m, ok := textMarshaler(v); ok {
+			text, err := m.MarshalText()
+			return string(text), err
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Slice:
+		// []byte
+		return string(v.Bytes()), nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrRequestDataTypeInvalid, v.Type())
+}
+
+// textMarshaler returns v as an encoding.TextMarshaler, also when only its pointer implements it.
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if v.Type().Implements(textMarshalerType) {
+		return v.Interface().(encoding.TextMarshaler), true
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		return v.Addr().Interface().(encoding.TextMarshaler), true
+	}
+	return nil, false
+}
@@ -0,0 +1,75 @@
+package surf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type queryStatus int
+
+func (s queryStatus) MarshalText() ([]byte, error) {
+	return []byte(strings.Repeat("!", int(s))), nil
+}
+
+type queryPage struct {
+	Page  int `surf:"page"`
+	Limit int `surf:"limit,omitempty"`
+}
+
+type queryFilter struct {
+	queryPage
+	Since   time.Time   `surf:"since,layout:2006-01-02"`
+	Until   time.Time   `surf:"until"`
+	Status  queryStatus `surf:"status"`
+	Tags    []string    `surf:"tag"`
+	Owner   *string     `surf:"owner"`
+	Name    string
+	Ignored string `surf:"-"`
+	private string
+}
+
+func TestWithQueryFromStruct(t *testing.T) {
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	config := combineRequestConfig(
+		WithSetQuery("q", "surf"),
+		WithQueryFromStruct(&queryFilter{
+			queryPage: queryPage{Page: 2},
+			Since:     since,
+			Until:     since,
+			Status:    3,
+			Tags:      []string{"a", "b"},
+			Name:      "n",
+			Ignored:   "x",
+			private:   "x",
+		}),
+	)
+	if config.err != nil {
+		t.Fatal(config.err)
+	}
+
+	expect := "Name=n&page=2&q=surf&since=2024-01-02&status=%21%21%21&tag=a&tag=b&until=2024-01-02T03%3A04%3A05Z"
+	if qs := config.Query.Encode(); qs != expect {
+		t.Fatalf("query expect %s output %s.", expect, qs)
+	}
+
+	config = combineRequestConfig(WithQueryFromStruct("surf"))
+	if !errors.Is(config.err, ErrRequestDataTypeInvalid) {
+		t.Fatalf("expect ErrRequestDataTypeInvalid output %v.", config.err)
+	}
+}
+
+func TestWithQueryMap(t *testing.T) {
+	config := combineRequestConfig(
+		WithSetQuery("q", "surf"),
+		WithSetQuery("page", "1"),
+		WithQueryMap(map[string]string{"page": "2", "limit": "10"}),
+		WithQueryMapSlice(map[string][]string{"tag": {"a", "b"}, "q": {"go"}}),
+	)
+
+	expect := "limit=10&page=2&q=surf&q=go&tag=a&tag=b"
+	if qs := config.Query.Encode(); qs != expect {
+		t.Fatalf("query expect %s output %s.", expect, qs)
+	}
+}
@@ -1,11 +1,22 @@
 package surf
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 )
 
 // Response represents the HTTP response received after sending a request.
@@ -13,7 +24,60 @@ type Response struct {
 	originalResponse *http.Response
 	config           *RequestConfig
 	body             []byte
+	redirects        []RedirectInfo
 	Performance      *Performance
+
+	// text caches Text, it is reset when the body is released.
+	text atomic.Pointer[string]
+
+	// decompressFailed reports that body is the raw body, see WithDecompressFallback.
+	decompressFailed bool
+
+	// buffer holds body when it was read into a buffer of RequestConfig.BodyBufferPool, see Release.
+	buffer *bytes.Buffer
+
+	// rawBody is the unread response body of a streaming or raw response, nil once buffered or closed.
+	rawBody io.ReadCloser
+}
+
+// NewResponse builds a Response without sending a request, e.g. to unit test response interceptors
+// and validators. It decodes with the default codecs and has no request: Request returns nil.
+func NewResponse(statusCode int, header http.Header, body []byte) *Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	jsonMarshal, jsonUnmarshal := defaultJSONCodec()
+	xmlMarshal, xmlUnmarshal := defaultXMLCodec()
+	return &Response{
+		originalResponse: &http.Response{
+			Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+			StatusCode:    statusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          http.NoBody,
+			ContentLength: int64(len(body)),
+		},
+		config: &RequestConfig{
+			Method:        http.MethodGet,
+			Context:       context.Background(),
+			JSONMarshal:   jsonMarshal,
+			JSONUnmarshal: jsonUnmarshal,
+			XMLMarshal:    xmlMarshal,
+			XMLUnmarshal:  xmlUnmarshal,
+		},
+		body:        body,
+		Performance: &Performance{},
+	}
+}
+
+// RedirectInfo describes a single redirect hop.
+type RedirectInfo struct {
+	// URL is the URL that responded with the redirect.
+	URL string
+	// StatusCode is the redirect status code.
+	StatusCode int
 }
 
 // Body returns the raw body of the HTTP response.
@@ -25,23 +89,134 @@ func (r *Response) Body() []byte {
 }
 
 // BodyReader returns the response body as an io.Reader.
+// Every call returns a new reader positioned at the start of the body.
 func (r *Response) BodyReader() io.Reader {
 	return bytes.NewReader(r.body)
 }
 
+// BodyReadCloser returns the response body as an io.ReadCloser, the shape of http.Response.Body,
+// for code expecting one. For a buffered response it reads from the buffered body and Close is a no-op.
+// For a streaming response it is the live body, closing it releases the connection as Close does.
+func (r *Response) BodyReadCloser() io.ReadCloser {
+	if r.rawBody != nil {
+		return r.rawBody
+	}
+	return io.NopCloser(bytes.NewReader(r.body))
+}
+
 // Json parses the JSON response body and stores the result in the provided variable (v).
+// A leading UTF-8 BOM is stripped before decoding.
 func (r *Response) Json(v interface{}) error {
-	return r.config.JSONUnmarshal(r.body, &v)
+	return r.config.JSONUnmarshal(trimBOM(r.body), &v)
+}
+
+// JsonUseNumber parses the JSON response body into v, decoding numbers as json.Number
+// instead of float64 so that large integers keep their precision.
+// It always uses encoding/json, regardless of the configured JSONUnmarshal.
+func (r *Response) JsonUseNumber(v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(trimBOM(r.body)))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// JsonStrict parses the JSON response body into v, failing on fields that v does not declare.
+// It always uses encoding/json, regardless of the configured JSONUnmarshal.
+func (r *Response) JsonStrict(v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(trimBOM(r.body)))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// JsonMap parses the JSON response body as an object.
+// An error wrapping ErrJsonTypeMismatch is returned when the top-level JSON value is not an object.
+func (r *Response) JsonMap() (map[string]interface{}, error) {
+	var v interface{}
+	if err := r.config.JSONUnmarshal(trimBOM(r.body), &v); err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: expected object, got %s", ErrJsonTypeMismatch, jsonTypeName(v))
+	}
+	return m, nil
+}
+
+// JsonArray parses the JSON response body as an array.
+// An error wrapping ErrJsonTypeMismatch is returned when the top-level JSON value is not an array.
+func (r *Response) JsonArray() ([]interface{}, error) {
+	var v interface{}
+	if err := r.config.JSONUnmarshal(trimBOM(r.body), &v); err != nil {
+		return nil, err
+	}
+	a, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: expected array, got %s", ErrJsonTypeMismatch, jsonTypeName(v))
+	}
+	return a, nil
 }
 
 // XML parses the xml response body and stores the result in the provided variable (v).
+// A leading UTF-8 BOM is stripped before decoding.
 func (r *Response) XML(v interface{}) error {
-	return r.config.XMLUnmarshal(r.body, &v)
+	return r.config.XMLUnmarshal(trimBOM(r.body), &v)
 }
 
-// Text returns the response body as a string.
+// Unmarshal decodes the response body into the provided variable (v) based on the response Content-Type.
+// Decoders registered with RegisterResponseDecoder take precedence over the built-in JSON and XML decoding.
+func (r *Response) Unmarshal(v interface{}) error {
+	contentType := r.originalResponse.Header.Get(headerContentType)
+
+	if fn, ok := lookupResponseDecoder(contentType); ok {
+		return fn(r.body, v)
+	}
+
+	switch {
+	case regJsonHeader.MatchString(contentType):
+		return r.Json(v)
+	case regXmlHeader.MatchString(contentType):
+		return r.XML(v)
+	}
+
+	return fmt.Errorf("%w: %q", ErrResponseContentTypeUnsupported, contentType)
+}
+
+// decodeResult decodes the body into RequestConfig.Result or RequestConfig.ErrorResult depending on the status.
+// A response without Content-Type is decoded as JSON.
+func (r *Response) decodeResult() error {
+	target := r.config.Result
+	if !r.Ok() {
+		target = r.config.ErrorResult
+	}
+	if target == nil || len(r.body) == 0 || r.config.Stream {
+		return nil
+	}
+
+	if r.originalResponse.Header.Get(headerContentType) == "" {
+		return r.Json(target)
+	}
+	err := r.Unmarshal(target)
+	// Keep the status and body of an error page the ErrorResult cannot describe
+	if !r.Ok() && errors.Is(err, ErrResponseContentTypeUnsupported) {
+		return nil
+	}
+	return err
+}
+
+// Image decodes the response body as an image, returning the image and its format name.
+// GIF, JPEG and PNG are supported by default, other formats can be added with image.RegisterFormat.
+func (r *Response) Image() (image.Image, string, error) {
+	return image.Decode(bytes.NewReader(r.body))
+}
+
+// Text returns the response body as a string, without a leading UTF-8 BOM.
+// The string is built on the first call and cached for the next ones.
 func (r *Response) Text() string {
-	return string(r.body)
+	if text := r.text.Load(); text != nil {
+		return *text
+	}
+	text := string(trimBOM(r.body))
+	r.text.Store(&text)
+	return text
 }
 
 // SaveToFile saves the response body to a file with the specified filename.
@@ -50,6 +225,27 @@ func (r *Response) SaveToFile(filename string) error {
 	return err
 }
 
+// SaveToFileMkdir saves the response body to a file like SaveToFile, first creating the missing
+// parent directories of filename with the permission bits perm.
+func (r *Response) SaveToFileMkdir(filename string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(filename), perm); err != nil {
+		return err
+	}
+	return r.SaveToFile(filename)
+}
+
+// DecompressFailed reports whether the body could not be decoded according to its Content-Encoding
+// and is the raw body as received instead, see WithDecompressFallback.
+func (r *Response) DecompressFailed() bool {
+	return r.decompressFailed
+}
+
+// DecodedLength returns the length of the decoded body, after decompression, which differs from
+// the Content-Length header of a compressed response. It is zero for streaming responses.
+func (r *Response) DecodedLength() int {
+	return len(r.body)
+}
+
 // StatusText returns the status text part of the HTTP status code and reason.
 func (r *Response) StatusText() string {
 	status := r.originalResponse.Status
@@ -68,10 +264,20 @@ func (r *Response) Status() int {
 }
 
 // Headers returns the HTTP headers of the response.
+// They are the headers as received: Content-Length is the size on the wire, before decompression,
+// use DecodedLength for the size of Body.
 func (r *Response) Headers() http.Header {
 	return r.originalResponse.Header
 }
 
+// Trailers returns the HTTP trailers of the response.
+// Trailers are only populated once the body has been read completely,
+// which is always the case for buffered responses since the body is drained before returning.
+// For a streaming response they are available once its body has been read to the end.
+func (r *Response) Trailers() http.Header {
+	return r.originalResponse.Trailer
+}
+
 // Cookies returns the cookies set in the HTTP response.
 func (r *Response) Cookies() []*http.Cookie {
 	return r.originalResponse.Cookies()
@@ -87,6 +293,42 @@ func (r *Response) Failed() bool {
 	return r.originalResponse.StatusCode >= http.StatusBadRequest
 }
 
+// Is2xx reports whether the status code is 2xx, the same as Ok.
+func (r *Response) Is2xx() bool {
+	return r.statusClass() == 2
+}
+
+// Is3xx reports whether the status code is 3xx.
+func (r *Response) Is3xx() bool {
+	return r.statusClass() == 3
+}
+
+// Is4xx reports whether the status code is 4xx, a client error.
+func (r *Response) Is4xx() bool {
+	return r.statusClass() == 4
+}
+
+// Is5xx reports whether the status code is 5xx, a server error.
+func (r *Response) Is5xx() bool {
+	return r.statusClass() == 5
+}
+
+// IsRedirect reports whether the status code is a redirect to the Location header:
+// 301, 302, 303, 307 or 308.
+func (r *Response) IsRedirect() bool {
+	switch r.originalResponse.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// statusClass returns the first digit of the status code.
+func (r *Response) statusClass() int {
+	return r.originalResponse.StatusCode / 100
+}
+
 // Config returns the request configuration associated with the response.
 func (r *Response) Config() *RequestConfig {
 	return r.config
@@ -105,6 +347,137 @@ func (r *Response) Request() *http.Request {
 	return r.originalResponse.Request
 }
 
+// RequestID returns the request ID sent with the request, empty if request ID propagation is disabled.
+func (r *Response) RequestID() string {
+	return r.config.RequestID()
+}
+
+// Redirects returns the redirect hops followed before the final response, in order.
+func (r *Response) Redirects() []RedirectInfo {
+	return r.redirects
+}
+
+// FinalURL returns the URL of the final response after following redirects.
+func (r *Response) FinalURL() string {
+	if r.originalResponse == nil || r.originalResponse.Request == nil {
+		return ""
+	}
+	return r.originalResponse.Request.URL.String()
+}
+
+// NDJSON reads the newline-delimited JSON body line by line, calling fn for each non-empty line
+// with a decode function unmarshalling that line. A decode error names the line number.
+// Reading stops at the end of the body, on the first error returned by fn, or when the request
+// context is done. Use WithStream to process the lines while they are received, the streaming
+// body is closed once NDJSON returns.
+func (r *Response) NDJSON(fn func(decode func(v interface{}) error) error) error {
+	var reader io.Reader = bytes.NewReader(r.body)
+	if r.rawBody != nil {
+		reader = r.rawBody
+		defer r.Close()
+	}
+
+	br := bufio.NewReader(reader)
+	for line := 1; ; line++ {
+		if err := r.config.Context.Err(); err != nil {
+			return err
+		}
+
+		data, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			n := line
+			decode := func(v interface{}) error {
+				if err := r.config.JSONUnmarshal(data, v); err != nil {
+					return fmt.Errorf("surf: ndjson line %d: %w", n, err)
+				}
+				return nil
+			}
+			if fnErr := fn(decode); fnErr != nil {
+				return fnErr
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Close releases the connection of a streaming response by closing its unread body.
+// It is safe to call multiple times and is a no-op for buffered responses, whose body is already closed.
+func (r *Response) Close() error {
+	if r.rawBody == nil {
+		return nil
+	}
+	err := r.rawBody.Close()
+	r.rawBody = nil
+	return err
+}
+
+// readBody reads the decoded body of a buffered response. bufferSize is the initial buffer capacity
+// used when the response has no Content-Length. With RequestConfig.DecompressFallback, the raw body
+// is kept when it cannot be decoded.
+func (r *Response) readBody(bufferSize int) error {
+	var raw []byte
+	if r.config.DecompressFallback {
+		var err error
+		if raw, err = readRawBody(r.originalResponse); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if r.config.BodyBufferPool != nil {
+		err = r.readPooledBody(r.config.MaxBodyLength)
+	} else {
+		r.body, err = readBody(r.originalResponse, r.config.MaxBodyLength, bufferSize)
+	}
+
+	if err != nil && r.config.DecompressFallback && errors.Is(err, ErrDecompress) {
+		r.config.Logger.Printf("WARN: Using the raw body of %s %s, decompression failed: %v\n",
+			r.config.Method, r.originalResponse.Request.URL, err)
+		r.body = raw
+		r.decompressFailed = true
+		return nil
+	}
+	return err
+}
+
+// readPooledBody reads the body into a buffer of RequestConfig.BodyBufferPool.
+// The buffer is put back into the pool when reading fails.
+func (r *Response) readPooledBody(maxBodyLength int) error {
+	buf, _ := r.config.BodyBufferPool.Get().(*bytes.Buffer)
+	if buf == nil {
+		buf = new(bytes.Buffer)
+	}
+	buf.Reset()
+	r.buffer = buf
+
+	if err := readBodyBuffer(r.originalResponse, maxBodyLength, buf); err != nil {
+		r.Release()
+		return err
+	}
+	r.body = buf.Bytes()
+	return nil
+}
+
+// Release puts the buffer holding the body back into the pool set with WithResponseBodyBuffer,
+// after which the body is empty. The data returned by Body before must no longer be used.
+// It is safe to call multiple times and is a no-op for responses without a pooled buffer.
+func (r *Response) Release() {
+	if r.buffer == nil {
+		return
+	}
+	r.body = nil
+	r.text.Store(nil)
+	r.buffer.Reset()
+	r.config.BodyBufferPool.Put(r.buffer)
+	r.buffer = nil
+}
+
 // OriginalResponse returns the original HTTP response.
 func (r *Response) OriginalResponse() *http.Response {
 	return r.originalResponse
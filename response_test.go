@@ -0,0 +1,365 @@
+package surf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestResponse_JsonBOM(t *testing.T) {
+	resp := &Response{
+		originalResponse: &http.Response{StatusCode: http.StatusOK, Header: http.Header{}},
+		config:           &RequestConfig{JSONUnmarshal: json.Unmarshal},
+		body:             append([]byte{0xEF, 0xBB, 0xBF}, `{"name":"surf"}`...),
+	}
+
+	var data struct {
+		Name string `json:"name"`
+	}
+	if err := resp.Json(&data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Name != "surf" {
+		t.Fatalf("json decode expect surf output %s.", data.Name)
+	}
+	if resp.Text() != `{"name":"surf"}` {
+		t.Fatalf("text expect BOM stripped output %q.", resp.Text())
+	}
+}
+
+func TestResponse_JsonMapArray(t *testing.T) {
+	newResponse := func(body string) *Response {
+		return &Response{
+			originalResponse: &http.Response{StatusCode: http.StatusOK, Header: http.Header{}},
+			config:           &RequestConfig{JSONUnmarshal: json.Unmarshal},
+			body:             []byte(body),
+		}
+	}
+
+	m, err := newResponse(`{"a":1}`).JsonMap()
+	if err != nil || m["a"] != float64(1) {
+		t.Fatalf("json map unexpected %v %v.", m, err)
+	}
+	if _, err = newResponse(`[1]`).JsonMap(); !errors.Is(err, ErrJsonTypeMismatch) {
+		t.Fatalf("json map expect type mismatch output %v.", err)
+	}
+
+	a, err := newResponse(`[1,2]`).JsonArray()
+	if err != nil || len(a) != 2 {
+		t.Fatalf("json array unexpected %v %v.", a, err)
+	}
+	if _, err = newResponse(`{}`).JsonArray(); !errors.Is(err, ErrJsonTypeMismatch) {
+		t.Fatalf("json array expect type mismatch output %v.", err)
+	}
+}
+
+func TestResponse_NDJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 1; i <= 3; i++ {
+			_, _ = fmt.Fprintf(w, "{\"n\":%d}\n", i)
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write([]byte("not json\n"))
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL, WithStream())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := 0
+	err = resp.NDJSON(func(decode func(v interface{}) error) error {
+		var item struct {
+			N int `json:"n"`
+		}
+		if err := decode(&item); err != nil {
+			return err
+		}
+		sum += item.N
+		return nil
+	})
+	if sum != 6 {
+		t.Fatalf("ndjson sum expect 6 output %d.", sum)
+	}
+	if err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Fatalf("expect decode error on line 4 output %v.", err)
+	}
+}
+
+func TestResponse_RawResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		_, _ = zw.Write([]byte(`{"name":"surf"}`))
+		_ = zw.Close()
+	}))
+	defer server.Close()
+
+	var result map[string]interface{}
+	resp, err := New(nil).Get(server.URL, WithResponseBodyConsumed(false), WithResult(&result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Body()) != 0 || result != nil {
+		t.Fatal("expect the body not to be consumed")
+	}
+
+	original := resp.OriginalResponse()
+	zr, err := gzip.NewReader(original.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"name":"surf"}` {
+		t.Fatalf("raw body expect {\"name\":\"surf\"} output %s.", data)
+	}
+	if err := original.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResponse_JsonUseNumber(t *testing.T) {
+	resp := &Response{
+		originalResponse: &http.Response{StatusCode: http.StatusOK, Header: http.Header{}},
+		config:           &RequestConfig{JSONUnmarshal: json.Unmarshal},
+		body:             []byte(`{"id":9007199254740993}`),
+	}
+
+	var data map[string]interface{}
+	if err := resp.JsonUseNumber(&data); err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := data["id"].(json.Number); !ok || id.String() != "9007199254740993" {
+		t.Fatalf("json number expect 9007199254740993 output %v.", data["id"])
+	}
+}
+
+func TestResponse_JsonStrict(t *testing.T) {
+	resp := &Response{
+		originalResponse: &http.Response{StatusCode: http.StatusOK, Header: http.Header{}},
+		config:           &RequestConfig{JSONUnmarshal: json.Unmarshal},
+		body:             []byte(`{"name":"surf","extra":true}`),
+	}
+
+	var data struct {
+		Name string `json:"name"`
+	}
+	if err := resp.JsonStrict(&data); err == nil || !strings.Contains(err.Error(), "extra") {
+		t.Fatalf("expect unknown field error output %v.", err)
+	}
+}
+
+func TestResponse_SaveToFileMkdir(t *testing.T) {
+	resp := &Response{
+		originalResponse: &http.Response{StatusCode: http.StatusOK, Header: http.Header{}},
+		body:             []byte("surf"),
+	}
+
+	filename := filepath.Join(t.TempDir(), "a", "b", "surf.txt")
+	if err := resp.SaveToFileMkdir(filename, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "surf" {
+		t.Fatalf("file expect surf output %s.", data)
+	}
+}
+
+func TestResponse_DecodedLength(t *testing.T) {
+	body := strings.Repeat("surf", 1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write([]byte(body))
+		_ = zw.Close()
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		_, _ = w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.DecodedLength() != len(body) {
+		t.Fatalf("decoded length expect %d output %d.", len(body), resp.DecodedLength())
+	}
+	if wire, _ := strconv.Atoi(resp.Headers().Get("Content-Length")); wire == 0 || wire >= len(body) {
+		t.Fatalf("content length expect the compressed size output %d.", wire)
+	}
+}
+
+func TestResponse_StatusClass(t *testing.T) {
+	tests := []struct {
+		status   int
+		expect   string
+		redirect bool
+	}{
+		{http.StatusOK, "2xx", false},
+		{http.StatusNotModified, "3xx", false},
+		{http.StatusFound, "3xx", true},
+		{http.StatusNotFound, "4xx", false},
+		{http.StatusBadGateway, "5xx", false},
+	}
+	for _, tt := range tests {
+		resp := &Response{originalResponse: &http.Response{StatusCode: tt.status}}
+		classes := map[string]bool{"2xx": resp.Is2xx(), "3xx": resp.Is3xx(), "4xx": resp.Is4xx(), "5xx": resp.Is5xx()}
+		for class, ok := range classes {
+			if ok != (class == tt.expect) {
+				t.Fatalf("status %d expect %s output %v.", tt.status, tt.expect, classes)
+			}
+		}
+		if resp.IsRedirect() != tt.redirect {
+			t.Fatalf("status %d redirect expect %v output %v.", tt.status, tt.redirect, resp.IsRedirect())
+		}
+	}
+}
+
+func TestWithResponseBodyBuffer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"surf"}`))
+	}))
+	defer server.Close()
+
+	var allocated int
+	pool := &sync.Pool{New: func() interface{} {
+		allocated++
+		return new(bytes.Buffer)
+	}}
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	resp, err := New(nil).Get(server.URL, WithResponseBodyBuffer(pool), WithResult(&result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocated != 1 || resp.Text() != `{"name":"surf"}` || result.Name != "surf" {
+		t.Fatalf("body expect read into the pooled buffer output %q (%d allocated).", resp.Text(), allocated)
+	}
+
+	resp.Release()
+	resp.Release()
+	if len(resp.Body()) != 0 || resp.Text() != "" {
+		t.Fatalf("body expect empty after release output %q.", resp.Body())
+	}
+}
+
+func TestResponse_Trailers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Grpc-Status")
+		_, _ = w.Write([]byte("surf"))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "surf" || resp.Trailers().Get("Grpc-Status") != "0" {
+		t.Fatalf("trailer expect Grpc-Status 0 output %v.", resp.Trailers())
+	}
+
+	resp, err = New(nil).Get(server.URL, WithStream())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(resp.rawBody); err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Close()
+	if resp.Trailers().Get("Grpc-Status") != "0" {
+		t.Fatalf("stream trailer expect Grpc-Status 0 output %v.", resp.Trailers())
+	}
+}
+
+func TestResponse_BodyReadCloser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("surf"))
+	}))
+	defer server.Close()
+
+	for _, stream := range []bool{false, true} {
+		args := []WithRequestConfig{}
+		if stream {
+			args = append(args, WithStream())
+		}
+		resp, err := New(nil).Get(server.URL, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := resp.BodyReadCloser()
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := body.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "surf" {
+			t.Fatalf("stream %v: body expect surf output %s.", stream, data)
+		}
+	}
+}
+
+func TestResponse_TextCached(t *testing.T) {
+	resp := &Response{
+		originalResponse: &http.Response{StatusCode: http.StatusOK, Header: http.Header{}},
+		body:             []byte("surf"),
+	}
+	if resp.Text() != "surf" {
+		t.Fatalf("text expect surf output %s.", resp.Text())
+	}
+	if allocs := testing.AllocsPerRun(10, func() { _ = resp.Text() }); allocs != 0 {
+		t.Fatalf("cached text expect no allocation output %v.", allocs)
+	}
+}
+
+func TestNewResponse(t *testing.T) {
+	header := http.Header{"Content-Type": {"application/json"}}
+	resp := NewResponse(http.StatusBadGateway, header, []byte(`{"error":"upstream"}`))
+
+	rejectServerErrors := func(resp *Response) error {
+		if resp.Is5xx() {
+			m, err := resp.JsonMap()
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("server error: %v", m["error"])
+		}
+		return nil
+	}
+	if err := rejectServerErrors(resp); err == nil || err.Error() != "server error: upstream" {
+		t.Fatalf("interceptor expect server error output %v.", err)
+	}
+	if resp.StatusText() != "Bad Gateway" || resp.Text() != `{"error":"upstream"}` || resp.Request() != nil {
+		t.Fatalf("response unexpected %s %s.", resp.StatusText(), resp.Text())
+	}
+	if err := rejectServerErrors(NewResponse(http.StatusOK, nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,214 @@
+package surf
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+type (
+	// RetryCondition reports whether an attempt should be retried, resp is nil when err is not.
+	RetryCondition func(resp *Response, err error) bool
+
+	// RetryBackoff returns the delay before the given retry, starting at 1.
+	RetryBackoff func(retry int) time.Duration
+
+	// RetryConfig configures automatic retries.
+	//
+	// Only idempotent methods (GET, HEAD, PUT, DELETE and OPTIONS) are retried by default,
+	// so that a POST or PATCH is never sent twice by accident. Set AllowNonIdempotent, or send
+	// the request with an Idempotency-Key header, to retry them as well.
+	// Requests whose body cannot be replayed, such as a streamed io.Reader, are never retried.
+	RetryConfig struct {
+		// MaxRetries is the number of retries after the first attempt, zero disables retries.
+		MaxRetries int
+		// Condition decides whether an attempt is retried, defaults to DefaultRetryCondition.
+		Condition RetryCondition
+		// Backoff returns the delay before a retry, defaults to an exponential backoff
+		// starting at 100ms and capped at 5s.
+		Backoff RetryBackoff
+		// AllowNonIdempotent allows retrying POST, PATCH and the other non-idempotent methods.
+		AllowNonIdempotent bool
+		// FailFastOnDNS stops retrying when the host does not exist, see WithFailFastOnDNS.
+		FailFastOnDNS bool
+		// Budget caps the total time of the attempts and backoffs, see WithRetryBudget. Zero means no limit.
+		Budget time.Duration
+	}
+)
+
+// idempotentMethods are the methods retried by default.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// DefaultRetryCondition retries network errors, 429 Too Many Requests and 5xx responses
+// except 501 Not Implemented. Context cancellation, deadline and decompression errors are never retried.
+func DefaultRetryCondition(resp *Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrDecompress)
+	}
+	status := resp.Status()
+	return status == http.StatusTooManyRequests ||
+		(status >= http.StatusInternalServerError && status != http.StatusNotImplemented)
+}
+
+// RetryOnStatus returns a RetryCondition retrying responses with one of the given status codes,
+// and errors as DefaultRetryCondition does.
+func RetryOnStatus(codes ...int) RetryCondition {
+	statuses := make(map[int]bool, len(codes))
+	for _, code := range codes {
+		statuses[code] = true
+	}
+	return func(resp *Response, err error) bool {
+		if err != nil {
+			return DefaultRetryCondition(resp, err)
+		}
+		return statuses[resp.Status()]
+	}
+}
+
+// DefaultRetryBackoff is an exponential backoff starting at 100ms and capped at 5s.
+func DefaultRetryBackoff(retry int) time.Duration {
+	const maxBackoff = 5 * time.Second
+	if retry > 16 {
+		return maxBackoff
+	}
+	backoff := 100 * time.Millisecond << (retry - 1)
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// clone returns a copy of rc, nil if rc is nil.
+func (rc *RetryConfig) clone() *RetryConfig {
+	if rc == nil {
+		return nil
+	}
+	cloned := *rc
+	return &cloned
+}
+
+// merge returns a copy of rc with the unset fields taken from config.
+func (rc *RetryConfig) merge(config *RetryConfig) *RetryConfig {
+	if rc == nil && config == nil {
+		return nil
+	}
+
+	merged := &RetryConfig{}
+	if config != nil {
+		*merged = *config
+	}
+	if rc == nil {
+		return merged
+	}
+
+	if rc.MaxRetries != 0 {
+		merged.MaxRetries = rc.MaxRetries
+	}
+	if rc.Condition != nil {
+		merged.Condition = rc.Condition
+	}
+	if rc.Backoff != nil {
+		merged.Backoff = rc.Backoff
+	}
+	if rc.AllowNonIdempotent {
+		merged.AllowNonIdempotent = true
+	}
+	if rc.FailFastOnDNS {
+		merged.FailFastOnDNS = true
+	}
+	if rc.Budget != 0 {
+		merged.Budget = rc.Budget
+	}
+	return merged
+}
+
+// retryConfig returns the request RetryConfig, creating it if needed.
+func (rc *RequestConfig) retryConfig() *RetryConfig {
+	if rc.Retry == nil {
+		rc.Retry = &RetryConfig{}
+	}
+	return rc.Retry
+}
+
+// shouldRetry reports whether the attempt, the retry-th one starting at 1, should be retried.
+func (rc *RequestConfig) shouldRetry(req *http.Request, retry int, resp *Response, err error) bool {
+	r := rc.Retry
+	if r == nil || retry > r.MaxRetries {
+		return false
+	}
+	if rc.Context.Err() != nil {
+		return false
+	}
+	if !r.AllowNonIdempotent && !idempotentMethods[req.Method] && req.Header.Get(headerIdempotencyKey) == "" {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if r.FailFastOnDNS && isHostNotFound(err) {
+		return false
+	}
+
+	condition := r.Condition
+	if condition == nil {
+		condition = DefaultRetryCondition
+	}
+	return condition(resp, err)
+}
+
+// isHostNotFound reports whether err is a DNS error for a host that does not exist.
+func isHostNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// withinBudget reports whether a retry starting after elapsed, measured from the first attempt,
+// fits in the retry budget.
+func (r *RetryConfig) withinBudget(elapsed time.Duration) bool {
+	return r.Budget <= 0 || elapsed < r.Budget
+}
+
+// retryBackoff returns the delay before the given retry.
+func (rc *RequestConfig) retryBackoff(retry int) time.Duration {
+	if rc.Retry.Backoff != nil {
+		return rc.Retry.Backoff(retry)
+	}
+	return DefaultRetryBackoff(retry)
+}
+
+// retryRequest returns a copy of req with a fresh body for the next attempt.
+func retryRequest(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.Body = body
+	}
+	return next, nil
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
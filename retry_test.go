@@ -0,0 +1,149 @@
+package surf
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newFlakyServer(t *testing.T, failures int32) (*httptest.Server, *int32) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		body, _ := io.ReadAll(r.Body)
+		if n <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &attempts
+}
+
+func noBackoff(int) time.Duration {
+	return 0
+}
+
+func TestRetry_Idempotent(t *testing.T) {
+	server, attempts := newFlakyServer(t, 2)
+
+	resp, err := New(nil).Get(server.URL, WithRetry(3), WithRetryBackoff(noBackoff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != http.StatusOK || *attempts != 3 {
+		t.Fatalf("expect success after 3 attempts output %d after %d.", resp.Status(), *attempts)
+	}
+	if resp.Performance.Attempts != 3 || resp.Performance.TotalElapsed <= 0 {
+		t.Fatalf("performance attempts expect 3 output %d.", resp.Performance.Attempts)
+	}
+
+	resp, err = New(nil).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Performance.Attempts != 1 {
+		t.Fatalf("performance attempts expect 1 output %d.", resp.Performance.Attempts)
+	}
+}
+
+func TestRetry_NonIdempotent(t *testing.T) {
+	server, attempts := newFlakyServer(t, 1)
+
+	resp, err := New(nil).Post(server.URL, WithBody("surf"), WithRetry(3), WithRetryBackoff(noBackoff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != http.StatusServiceUnavailable || *attempts != 1 {
+		t.Fatalf("expect POST not retried output %d after %d.", resp.Status(), *attempts)
+	}
+
+	server, attempts = newFlakyServer(t, 1)
+	resp, err = New(nil).Post(server.URL, WithBody("surf"), WithRetry(3), WithRetryBackoff(noBackoff), WithRetryNonIdempotent())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "surf" || *attempts != 2 {
+		t.Fatalf("expect POST retried with its body output %q after %d.", resp.Text(), *attempts)
+	}
+
+	server, attempts = newFlakyServer(t, 1)
+	resp, err = New(nil).Post(server.URL, WithBody("surf"), WithRetry(3), WithRetryBackoff(noBackoff),
+		WithSetHeader(http.Header{"Idempotency-Key": {"key"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != http.StatusOK || *attempts != 2 {
+		t.Fatalf("expect POST with idempotency key retried output %d after %d.", resp.Status(), *attempts)
+	}
+}
+
+func TestRetry_FailFastOnDNS(t *testing.T) {
+	var dials int32
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return nil, &net.DNSError{Err: "no such host", Name: "surf.invalid", IsNotFound: true}
+		},
+	}
+	client := New(&Config{Client: &http.Client{Transport: transport}})
+	backoff := WithRetryBackoff(func(retry int) time.Duration { return 0 })
+
+	_, err := client.Get("http://surf.invalid", WithRetry(2), backoff)
+	var dnsErr *net.DNSError
+	if !errors.Is(err, ErrHostUnresolved) || !errors.As(err, &dnsErr) {
+		t.Fatalf("expect ErrHostUnresolved output %v.", err)
+	}
+	if n := atomic.LoadInt32(&dials); n != 3 {
+		t.Fatalf("dials expect 3 output %d.", n)
+	}
+
+	atomic.StoreInt32(&dials, 0)
+	_, err = client.Get("http://surf.invalid", WithRetry(2), backoff, WithFailFastOnDNS())
+	if !errors.Is(err, ErrHostUnresolved) {
+		t.Fatalf("expect ErrHostUnresolved output %v.", err)
+	}
+	if n := atomic.LoadInt32(&dials); n != 1 {
+		t.Fatalf("dials expect 1 output %d.", n)
+	}
+}
+
+func TestRetry_OnStatus(t *testing.T) {
+	server, attempts := newFlakyServer(t, 2)
+
+	resp, err := New(nil).Get(server.URL, WithRetry(3), WithRetryBackoff(noBackoff), WithRetryOnStatus(http.StatusBadGateway))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != http.StatusServiceUnavailable || *attempts != 1 {
+		t.Fatalf("expect 503 not retried output %d after %d.", resp.Status(), *attempts)
+	}
+
+	resp, err = New(nil).Get(server.URL, WithRetry(3), WithRetryBackoff(noBackoff), WithRetryOnStatus(http.StatusServiceUnavailable))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != http.StatusOK || *attempts != 3 {
+		t.Fatalf("expect success after 3 attempts output %d after %d.", resp.Status(), *attempts)
+	}
+}
+
+func TestRetry_Budget(t *testing.T) {
+	server, attempts := newFlakyServer(t, 10)
+
+	backoff := func(int) time.Duration { return 40 * time.Millisecond }
+	resp, err := New(nil).Get(server.URL, WithRetry(10), WithRetryBackoff(backoff), WithRetryBudget(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != http.StatusServiceUnavailable || *attempts != 3 {
+		t.Fatalf("expect 3 attempts within the budget output %d after %d.", resp.Status(), *attempts)
+	}
+}
@@ -0,0 +1,69 @@
+package surf
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type responseKey struct{}
+
+// roundTripper exposes the Surf request pipeline as an http.RoundTripper.
+type roundTripper struct {
+	surf *Surf
+}
+
+// RoundTripper returns an http.RoundTripper sending requests through the Surf pipeline,
+// so interceptors, decompression and Performance apply to clients of other libraries.
+// The returned response body is already decoded, use ResponseFromHTTP to get the Surf Response.
+func (s *Surf) RoundTripper() http.RoundTripper {
+	return &roundTripper{surf: s}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	config := &RequestConfig{
+		Url:     req.URL.String(),
+		Method:  req.Method,
+		Header:  req.Header.Clone(),
+		Context: req.Context(),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		defer req.Body.Close()
+		config.Body = io.Reader(req.Body)
+		// Keep the body replayable so that Surf can retry the request
+		if req.GetBody != nil {
+			getBody, contentLength := req.GetBody, req.ContentLength
+			config.RequestModifiers = append(config.RequestModifiers, func(r *http.Request) {
+				r.GetBody = getBody
+				r.ContentLength = contentLength
+			})
+		}
+	}
+
+	resp, err := rt.surf.Request(config)
+	if err != nil {
+		return nil, err
+	}
+
+	res := *resp.originalResponse
+	res.Header = res.Header.Clone()
+	res.Header.Del(headerContentEncoding)
+	res.Header.Set(headerContentLength, strconv.Itoa(len(resp.body)))
+	res.ContentLength = int64(len(resp.body))
+	res.Uncompressed = resp.ContentEncoding() != ""
+	res.Body = io.NopCloser(bytes.NewReader(resp.body))
+	res.Request = req.WithContext(context.WithValue(req.Context(), responseKey{}, resp))
+	return &res, nil
+}
+
+// ResponseFromHTTP returns the Surf Response behind an http.Response returned by Surf.RoundTripper.
+func ResponseFromHTTP(res *http.Response) (*Response, bool) {
+	if res == nil || res.Request == nil {
+		return nil, false
+	}
+	resp, ok := res.Request.Context().Value(responseKey{}).(*Response)
+	return resp, ok
+}
@@ -0,0 +1,88 @@
+package surf
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSurf_RoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentEncoding, "gzip")
+		gw := gzip.NewWriter(w)
+		_, _ = gw.Write([]byte("surf"))
+		_ = gw.Close()
+	}))
+	defer server.Close()
+
+	intercepted := false
+	s := New(&Config{
+		Client: &http.Client{},
+		ResponseInterceptors: ResponseInterceptorChain{
+			func(resp *Response) error {
+				intercepted = true
+				return nil
+			},
+		},
+	})
+
+	client := &http.Client{Transport: s.RoundTripper()}
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if string(body) != "surf" {
+		t.Fatalf("body expect surf output %s.", body)
+	}
+	if !intercepted {
+		t.Fatal("response interceptor not invoked")
+	}
+
+	resp, ok := ResponseFromHTTP(res)
+	if !ok || resp.Performance == nil {
+		t.Fatal("surf response not available")
+	}
+}
+
+func TestSurf_RoundTripperRetryBody(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	s := New(&Config{
+		Client: &http.Client{},
+		Retry: &RetryConfig{
+			MaxRetries: 1,
+			Backoff: func(retry int) time.Duration {
+				return time.Millisecond
+			},
+		},
+	})
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("surf"))
+	res, err := (&http.Client{Transport: s.RoundTripper()}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK || string(body) != "surf" || attempts != 2 {
+		t.Fatalf("retried body expect surf output %d %s %d.", res.StatusCode, body, attempts)
+	}
+}
@@ -0,0 +1,50 @@
+package surf
+
+import (
+	"net/http/httptrace"
+	"sync/atomic"
+)
+
+// Stats are the request and connection counters of a Surf instance, see Surf.Stats.
+type Stats struct {
+	// Requests is the number of requests sent, retries count once per request.
+	Requests uint64
+	// NewConns is the number of new connections dialed, including for retries and redirects.
+	NewConns uint64
+	// ReusedConns is the number of times an idle pooled connection was reused.
+	ReusedConns uint64
+}
+
+// ReuseRate returns the share of connections that were reused, between 0 and 1.
+func (s Stats) ReuseRate() float64 {
+	total := s.NewConns + s.ReusedConns
+	if total == 0 {
+		return 0
+	}
+	return float64(s.ReusedConns) / float64(total)
+}
+
+// stats holds the counters of a Surf instance.
+type stats struct {
+	requests    atomic.Uint64
+	newConns    atomic.Uint64
+	reusedConns atomic.Uint64
+}
+
+// gotConn records a connection obtained by the transport.
+func (s *stats) gotConn(info httptrace.GotConnInfo) {
+	if info.Reused {
+		s.reusedConns.Add(1)
+	} else {
+		s.newConns.Add(1)
+	}
+}
+
+// Stats returns a snapshot of the request and connection counters, to tune the connection pool.
+func (s *Surf) Stats() Stats {
+	return Stats{
+		Requests:    s.stats.requests.Load(),
+		NewConns:    s.stats.newConns.Load(),
+		ReusedConns: s.stats.reusedConns.Load(),
+	}
+}
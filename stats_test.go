@@ -0,0 +1,27 @@
+package surf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSurf_Stats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := New(&Config{Client: &http.Client{Transport: &http.Transport{}}})
+	for i := 0; i < 3; i++ {
+		if _, err := client.Get(server.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := client.Stats()
+	if stats.Requests != 3 || stats.NewConns != 1 || stats.ReusedConns != 2 {
+		t.Fatalf("stats expect 3 requests, 1 new and 2 reused conns output %+v.", stats)
+	}
+	if rate := stats.ReuseRate(); rate < 0.66 || rate > 0.67 {
+		t.Fatalf("reuse rate expect 2/3 output %f.", rate)
+	}
+}
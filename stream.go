@@ -0,0 +1,63 @@
+package surf
+
+import (
+	"io"
+	"sync"
+)
+
+// pipeBody is a request body backed by an io.Pipe, fed by a BodyWriter.
+// The writer goroutine is only started on the first Read so that
+// a request which is never sent does not leak it.
+type pipeBody struct {
+	fn   BodyWriter
+	once sync.Once
+	pr   *io.PipeReader
+}
+
+func newPipeBody(fn BodyWriter) *pipeBody {
+	return &pipeBody{fn: fn}
+}
+
+func (b *pipeBody) start() {
+	pr, pw := io.Pipe()
+	b.pr = pr
+	go func() {
+		pw.CloseWithError(b.fn(pw))
+	}()
+}
+
+// Read implements io.Reader.
+func (b *pipeBody) Read(p []byte) (int, error) {
+	b.once.Do(b.start)
+	return b.pr.Read(p)
+}
+
+// Close implements io.Closer, unblocking the writer if it is still running.
+func (b *pipeBody) Close() error {
+	b.once.Do(func() {})
+	if b.pr == nil {
+		return nil
+	}
+	return b.pr.Close()
+}
+
+// streamBody is the body of a streaming response, releasing the request resources when closed.
+type streamBody struct {
+	io.ReadCloser
+	releases []func()
+}
+
+// Close implements io.Closer.
+func (b *streamBody) Close() error {
+	err := b.ReadCloser.Close()
+	runReleases(b.releases)
+	b.releases = nil
+	return err
+}
+
+// runReleases runs the release functions in reverse order.
+func runReleases(releases []func()) {
+	for i := len(releases) - 1; i >= 0; i-- {
+		releases[i]()
+	}
+}
@@ -3,15 +3,29 @@ package surf
 import (
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Surf represents the main Surf client configuration.
 type Surf struct {
 	Config *Config
 	Debug  bool
+
+	limiter     concurrencyLimiter
+	hostLimiter hostLimiter
+	stats       stats
+	inFlight    inFlightRegistry
+
+	// referer is the URL of the last response, sent as Referer when Config.AutoReferer or WithAutoReferer is set.
+	referer atomic.Pointer[url.URL]
+
+	defaultOptionsMu sync.RWMutex
+	defaultOptions   []WithRequestConfig
 }
 
 // Default is the default Surf instance with the default configuration.
@@ -57,6 +71,8 @@ func (s *Surf) prepareRequest(config *RequestConfig) (*http.Request, error) {
 		req.AddCookie(cookie)
 	}
 
+	intercepted := s.Config.hasRequestInterceptors() || len(config.RequestInterceptors) > 0
+
 	err = s.Config.invokeRequestInterceptors(config)
 	if err != nil {
 		return nil, err
@@ -67,13 +83,14 @@ func (s *Surf) prepareRequest(config *RequestConfig) (*http.Request, error) {
 		return nil, err
 	}
 
-	// Update Request Body
-	if orgBody != config.Body {
+	// Update Request Body, re-encoding a struct or map body that interceptors may have edited in place
+	if config.bodyDirty || !isSameBody(orgBody, config.Body) || (intercepted && isEncodedBody(config.Body)) {
+		config.bodyDirty = false
 		newBody, err := config.getRequestBody()
 		if err != nil {
 			return nil, err
 		}
-		req.Body = io.NopCloser(newBody)
+		setRequestBody(req, newBody)
 	}
 
 	// Update Request URL
@@ -81,6 +98,11 @@ func (s *Surf) prepareRequest(config *RequestConfig) (*http.Request, error) {
 	if err != nil {
 		return nil, err
 	}
+	if !config.AllowUnresolvedParams {
+		if m := regPathParam.FindStringSubmatch(req.URL.Path); m != nil {
+			return nil, fmt.Errorf("%w: %s in %s", ErrUnresolvedPathParam, m[1], req.URL.Path)
+		}
+	}
 
 	// Update Request Headers
 	for key, values := range config.Header {
@@ -94,6 +116,19 @@ func (s *Surf) prepareRequest(config *RequestConfig) (*http.Request, error) {
 		req.AddCookie(cookie)
 	}
 
+	// Update Request Trailers, they require a chunked body
+	if len(config.Trailer) > 0 {
+		// An empty body must not be http.NoBody, which is never sent chunked
+		if req.Body == nil || req.Body == http.NoBody {
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader("")), nil
+			}
+			req.Body, _ = req.GetBody()
+		}
+		req.Trailer = config.Trailer.Clone()
+		req.ContentLength = -1
+	}
+
 	// Auto set Content-type header
 	config.setContentTypeHeader()
 
@@ -101,36 +136,207 @@ func (s *Surf) prepareRequest(config *RequestConfig) (*http.Request, error) {
 		req.Header.Set(headerUserAgent, UserAgent)
 	}
 	if req.Header.Get(headerAcceptEncoding) == "" {
-		req.Header.Set(headerAcceptEncoding, defaultAcceptEncoding)
+		req.Header.Set(headerAcceptEncoding, acceptEncoding())
 	}
 	if req.Header.Get(headerAccept) == "" {
-		req.Header.Set(headerAccept, defaultAccept)
+		// An XML request most likely expects an XML response
+		if regXmlHeader.MatchString(req.Header.Get(headerContentType)) {
+			req.Header.Set(headerAccept, defaultXmlAccept)
+		} else {
+			req.Header.Set(headerAccept, defaultAccept)
+		}
+	}
+	if req.Header.Get(headerAcceptLanguage) == "" && len(s.Config.AcceptLanguage) > 0 {
+		req.Header.Set(headerAcceptLanguage, acceptLanguage(s.Config.AcceptLanguage))
+	}
+
+	if (s.Config.AutoReferer || config.AutoReferer) && req.Header.Get(headerReferer) == "" {
+		if referer := s.referer.Load(); referer != nil && (referer.Scheme != "https" || req.URL.Scheme == "https") {
+			req.Header.Set(headerReferer, referer.String())
+		}
+	}
+
+	config.setRequestID(req)
+
+	for _, fn := range config.RequestModifiers {
+		fn(req)
 	}
 
 	if s.Debug {
-		log.Printf("DEBUG: Sending request to %s\n", req.URL)
-		log.Printf("DEBUG: Request headers:\n")
+		config.Logger.Printf("DEBUG: Sending request to %s\n", req.URL)
+		if config.requestID != "" {
+			config.Logger.Printf("DEBUG: Request ID: %s\n", config.requestID)
+		}
+		config.Logger.Printf("DEBUG: Request headers:\n")
 		for key, values := range req.Header {
 			for _, value := range values {
-				fmt.Printf("	%s: %s\n", key, value)
+				config.Logger.Printf("	%s: %s\n", key, value)
 			}
 		}
-		log.Printf("DEBUG: Request cookies: %v\n", req.Cookies())
+		config.Logger.Printf("DEBUG: Request cookies: %v\n", req.Cookies())
+		if s.Config.DebugBodyLimit >= 0 {
+			config.Logger.Printf("DEBUG: Request body: %s\n", debugBody(req, defaultValue(s.Config.DebugBodyLimit, defaultDebugBodyLimit)))
+		}
 	}
 
 	return req, nil
 }
 
 // Request performs an HTTP request using the provided configuration.
+// An error caused by a timeout matches ErrTimeout and one caused by cancelling the context
+// matches ErrCanceled, see errors.Is.
 func (s *Surf) Request(config *RequestConfig) (*Response, error) {
+	if defaults := s.getDefaultOptions(); len(defaults) > 0 {
+		config = config.withDefaults(combineRequestConfig(defaults...))
+	}
+	response, err := s.request(config)
+	if err != nil {
+		return nil, contextError(err)
+	}
+	return response, nil
+}
+
+// request performs an HTTP request, see Request.
+func (s *Surf) request(config *RequestConfig) (*Response, error) {
+	if config.err != nil {
+		closeBodies(config.Body)
+		return nil, config.err
+	}
+
 	config.mergeConfig(s.Config)
 
+	// Resources held until the request is done, handed over to the body of a streaming response
+	body := config.Body
+	releases := []func(){config.releaseContext, func() { closeBodies(body, config.Body) }}
+	defer func() {
+		runReleases(releases)
+	}()
+
+	var tracked *inFlightRequest
+	if s.Config.TrackInFlight {
+		var untrack func()
+		tracked, untrack = s.inFlight.add(config.Method, config.BuildURL())
+		releases = append(releases, untrack)
+	}
+
+	release, err := s.limiter.acquire(config.Context, s.Config.MaxConcurrentRequests)
+	if err != nil {
+		return nil, err
+	}
+	releases = append(releases, release)
+
 	req, err := s.prepareRequest(config)
 	if err != nil {
 		return nil, err
 	}
 
+	releaseHost, err := s.hostLimiter.acquire(config.Context, req.URL.Host, s.Config.MaxConcurrentRequestsPerHost)
+	if err != nil {
+		return nil, err
+	}
+	releases = append(releases, releaseHost)
+
+	if err = sleepContext(config.Context, config.Delay); err != nil {
+		return nil, err
+	}
+
+	s.stats.requests.Add(1)
+	config.clientTrace.onGotConn = s.stats.gotConn
+
+	var response *Response
+	start := time.Now()
+	attempts := 0
+	for retry := 1; ; retry++ {
+		attempts++
+		tracked.setAttempt(attempts)
+		response, err = s.send(config, req)
+		if !config.shouldRetry(req, retry, response, err) {
+			break
+		}
+		backoff := config.retryBackoff(retry)
+		if !config.Retry.withinBudget(time.Since(start) + backoff) {
+			break
+		}
+		if response != nil {
+			response.Close()
+			response.Release()
+		}
+
+		if s.Debug {
+			config.Logger.Printf("DEBUG: Retrying request (%d/%d)\n", retry, config.Retry.MaxRetries)
+		}
+
+		if err = sleepContext(config.Context, backoff); err != nil {
+			return nil, err
+		}
+		if req, err = retryRequest(req); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Config.AutoReferer || config.AutoReferer {
+		referer := *response.originalResponse.Request.URL
+		referer.User = nil
+		referer.Fragment = ""
+		referer.RawFragment = ""
+		s.referer.Store(&referer)
+	}
+
+	response.Performance.Attempts = attempts
+	response.Performance.TotalElapsed = time.Since(start)
+
+	// A response that is not returned is freed, its body holds the request resources once streaming
+	defer func() {
+		if err != nil {
+			response.Close()
+			response.Release()
+		}
+	}()
+
+	// A streaming response holds the request resources until its body is closed
+	if response.rawBody != nil {
+		response.rawBody = &streamBody{ReadCloser: response.rawBody, releases: releases}
+		releases = nil
+		if config.RawResponse {
+			response.originalResponse.Body = response.rawBody
+		}
+	}
+
+	err = s.Config.invokeResponseInterceptors(response)
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.invokeResponseInterceptors(response)
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.invokeResponseValidators(response)
+	if err != nil {
+		return nil, err
+	}
+
+	err = response.decodeResult()
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.invokeResultValidators(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// send sends a single attempt of the request, following redirects, and reads the response body.
+func (s *Surf) send(config *RequestConfig, req *http.Request) (*Response, error) {
 	redirects := 0
+	var redirectChain []RedirectInfo
 
 	for {
 		performance := &Performance{
@@ -140,22 +346,32 @@ func (s *Surf) Request(config *RequestConfig) (*Response, error) {
 		resp, err := config.Client.Do(req)
 		performance.record()
 		if err != nil {
-			return nil, err
+			return nil, transportError(err)
 		}
 
 		if s.Debug {
-			log.Printf("DEBUG: Received response with status code %d\n", resp.StatusCode)
-			log.Printf("DEBUG: Response headers:\n")
+			config.Logger.Printf("DEBUG: Received response with status code %d\n", resp.StatusCode)
+			config.Logger.Printf("DEBUG: Response headers:\n")
 			for key, values := range resp.Header {
 				for _, value := range values {
-					fmt.Printf("	%s: %s\n", key, value)
+					config.Logger.Printf("	%s: %s\n", key, value)
 				}
 			}
-			log.Printf("DEBUG: Response cookies: %v\n", resp.Cookies())
-			log.Printf("DEBUG: Response cost: %s\n", performance.ResponseTime)
+			config.Logger.Printf("DEBUG: Response cookies: %v\n", resp.Cookies())
+			config.Logger.Printf("DEBUG: Response cost: %s\n", performance.ResponseTime)
 		}
 
+		// Record hops followed by the http.Client itself
+		redirectChain = append(redirectChain, clientRedirects(resp)...)
+
 		if resp.StatusCode >= http.StatusMultipleChoices && resp.StatusCode < http.StatusBadRequest {
+			resp.Body.Close()
+
+			redirectChain = append(redirectChain, RedirectInfo{
+				URL:        resp.Request.URL.String(),
+				StatusCode: resp.StatusCode,
+			})
+
 			location := resp.Header.Get(headerLocation)
 			if location == "" {
 				return nil, ErrRedirectMissingLocation
@@ -184,29 +400,43 @@ func (s *Surf) Request(config *RequestConfig) (*Response, error) {
 			continue
 		}
 
-		body, err := readBody(resp, config.MaxBodyLength)
-		if err != nil {
-			return nil, err
+		if s.Config.OnResponseHeaders != nil {
+			if err := s.Config.OnResponseHeaders(resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
 		}
 
-		response := Response{
+		response := &Response{
 			originalResponse: resp,
 			config:           config,
-			body:             body,
+			redirects:        redirectChain,
 			Performance:      performance,
 		}
 
-		err = s.Config.invokeResponseInterceptors(&response)
-		if err != nil {
-			return nil, err
+		if config.BodyReadTimeout > 0 {
+			resp.Body = newTimeoutBody(resp.Body, config.BodyReadTimeout)
+		}
+
+		if config.StrictDecoding && !config.RawResponse {
+			if err := checkContentEncoding(resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
 		}
 
-		err = config.invokeResponseInterceptors(&response)
+		if config.RawResponse {
+			response.rawBody = resp.Body
+		} else if config.Stream {
+			response.rawBody, err = decodeBody(resp)
+		} else {
+			err = response.readBody(s.Config.DefaultReadBufferSize)
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		return &response, nil
+		return response, nil
 	}
 }
 
@@ -219,6 +449,27 @@ func (s *Surf) Upload(url string, file *multipartFile, args ...WithRequestConfig
 	)
 }
 
+// SetDefaultOptions sets options applied to every request of the instance, made with Request or
+// the method helpers such as Get and Post. They only fill what the request leaves unset: maps such
+// as headers are merged key by key, interceptors, validators and cookies of the defaults come first,
+// and the other fields are taken from the defaults when unset, so the per-request options override them.
+// As unset is the zero value, a request cannot turn off a boolean option enabled by the defaults,
+// such as WithStream, nor clear a field they set. The RequestConfig passed to Request is not modified,
+// the merged configuration is available from Response.Config. Calling it again replaces the previous options.
+func (s *Surf) SetDefaultOptions(opts ...WithRequestConfig) *Surf {
+	s.defaultOptionsMu.Lock()
+	s.defaultOptions = append([]WithRequestConfig(nil), opts...)
+	s.defaultOptionsMu.Unlock()
+	return s
+}
+
+// getDefaultOptions returns a copy of the options set with SetDefaultOptions.
+func (s *Surf) getDefaultOptions() []WithRequestConfig {
+	s.defaultOptionsMu.RLock()
+	defer s.defaultOptionsMu.RUnlock()
+	return append([]WithRequestConfig(nil), s.defaultOptions...)
+}
+
 // makeRequest is a helper function for creating an HTTP request with default or specified configuration.
 func (s *Surf) makeRequest(defaultUrl string, defaultMethod string, args ...WithRequestConfig) (*Response, error) {
 	config := combineRequestConfig(args...)
@@ -228,7 +479,7 @@ func (s *Surf) makeRequest(defaultUrl string, defaultMethod string, args ...With
 	if config.Method == "" {
 		config.Method = defaultMethod
 	}
-	return s.Request(&config)
+	return s.Request(config)
 }
 
 func (s *Surf) Get(url string, args ...WithRequestConfig) (*Response, error) {
@@ -269,23 +520,5 @@ func (s *Surf) Trace(url string, args ...WithRequestConfig) (*Response, error) {
 
 // CloneDefaultConfig creates a deep copy of the default configuration.
 func (s *Surf) CloneDefaultConfig() *Config {
-	return &Config{
-		BaseURL:              s.Config.BaseURL,
-		Header:               s.Config.Header.Clone(),
-		Timeout:              s.Config.Timeout,
-		Params:               cloneMap(s.Config.Params),
-		Query:                cloneURLValues(s.Config.Query),
-		Cookies:              append([]*http.Cookie(nil), s.Config.Cookies...),
-		CookieJar:            s.Config.CookieJar,
-		QuerySerializer:      s.Config.QuerySerializer,
-		RequestInterceptors:  append([]RequestInterceptor(nil), s.Config.RequestInterceptors...),
-		ResponseInterceptors: append([]ResponseInterceptor(nil), s.Config.ResponseInterceptors...),
-		MaxBodyLength:        s.Config.MaxBodyLength,
-		MaxRedirects:         s.Config.MaxRedirects,
-		Client:               s.Config.Client,
-		JSONMarshal:          s.Config.JSONMarshal,
-		JSONUnmarshal:        s.Config.JSONUnmarshal,
-		XMLMarshal:           s.Config.XMLMarshal,
-		XMLUnmarshal:         s.Config.XMLUnmarshal,
-	}
+	return s.Config.clone()
 }
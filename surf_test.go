@@ -0,0 +1,1276 @@
+package surf
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSurf_BodyWriter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Post(server.URL, WithBody(BodyWriter(func(w io.Writer) error {
+		_, err := io.WriteString(w, "streamed")
+		return err
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "streamed" {
+		t.Fatalf("body expect streamed output %s.", resp.Text())
+	}
+
+	writerErr := errors.New("writer failed")
+	_, err = New(nil).Post(server.URL, WithBody(BodyWriter(func(w io.Writer) error {
+		return writerErr
+	})))
+	if !errors.Is(err, writerErr) {
+		t.Fatalf("expect writer error output %v.", err)
+	}
+}
+
+func TestWithStreamJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Content-Length", strconv.FormatInt(r.ContentLength, 10))
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Post(server.URL, WithBody(map[string]string{"name": "surf"}), WithStreamJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "{\"name\":\"surf\"}\n" || resp.Headers().Get("X-Content-Length") != "-1" {
+		t.Fatalf("body expect streamed json output %s.", resp.Text())
+	}
+	if resp.Headers().Get("X-Content-Type") != defaultJsonContentType {
+		t.Fatalf("content type expect json output %s.", resp.Headers().Get("X-Content-Type"))
+	}
+
+	_, err = New(nil).Post(server.URL, WithBody(map[string]interface{}{"ch": make(chan int)}), WithStreamJSON())
+	var unsupported *json.UnsupportedTypeError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("expect encode error output %v.", err)
+	}
+}
+
+func TestSurf_TimeoutDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	data := []struct {
+		name    string
+		ctx     time.Duration
+		timeout time.Duration
+	}{
+		{name: "context shorter", ctx: 50 * time.Millisecond, timeout: 5 * time.Second},
+		{name: "timeout shorter", ctx: 5 * time.Second, timeout: 50 * time.Millisecond},
+	}
+
+	for _, item := range data {
+		ctx, cancel := context.WithTimeout(context.Background(), item.ctx)
+		start := time.Now()
+		_, err := New(nil).Get(server.URL, WithTimeoutContext(ctx, item.timeout))
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%s: expect deadline exceeded output %v.", item.name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Fatalf("%s: expect early deadline, took %s.", item.name, elapsed)
+		}
+	}
+}
+
+func TestSurf_TimeoutCanceledErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	_, err := New(nil).Get(server.URL, WithTimeoutContext(context.Background(), 50*time.Millisecond))
+	if !errors.Is(err, ErrTimeout) || errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timeout expect ErrTimeout output %v.", err)
+	}
+
+	_, err = New(&Config{Client: &http.Client{Timeout: 50 * time.Millisecond}}).Get(server.URL)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("client timeout expect ErrTimeout output %v.", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = New(nil).Get(server.URL, WithContext(ctx))
+	if !errors.Is(err, ErrCanceled) || errors.Is(err, ErrTimeout) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancel expect ErrCanceled output %v.", err)
+	}
+}
+
+func TestConfig_OnResponseHeaders(t *testing.T) {
+	var written atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(1<<30))
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 1024; i++ {
+			n, err := w.Write(make([]byte, 1<<20))
+			written.Add(int64(n))
+			if err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	tooLarge := errors.New("body too large")
+	client := New(&Config{OnResponseHeaders: func(resp *http.Response) error {
+		if resp.ContentLength > 1<<20 {
+			return tooLarge
+		}
+		return nil
+	}})
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, tooLarge) {
+		t.Fatalf("expect body too large output %v.", err)
+	}
+	if n := written.Load(); n >= 1<<30 {
+		t.Fatalf("body expect not fully read output %d bytes written.", n)
+	}
+}
+
+func TestWithRequestDelay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	start := time.Now()
+	if _, err := New(nil).Get(server.URL, WithRequestDelay(50*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expect delayed request, took %s.", elapsed)
+	}
+
+	_, err := New(nil).Get(server.URL, WithRequestDelay(time.Second), WithTimeoutContext(context.Background(), 50*time.Millisecond))
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expect ErrTimeout during the delay output %v.", err)
+	}
+}
+
+func TestWithIfMatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Match") != `"v2"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Put(server.URL, WithBody("surf"), WithIfMatch(`"v2"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != http.StatusNoContent {
+		t.Fatalf("status expect 204 output %d.", resp.Status())
+	}
+
+	_, err = New(nil).Delete(server.URL, WithIfMatch(`"v1"`))
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expect ErrPreconditionFailed output %v.", err)
+	}
+}
+
+type closeCounter struct {
+	io.Reader
+	closed int32
+}
+
+func (c *closeCounter) Close() error {
+	atomic.AddInt32(&c.closed, 1)
+	return nil
+}
+
+func TestSurf_CloseBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer server.Close()
+
+	body := &closeCounter{Reader: strings.NewReader("surf")}
+	resp, err := New(nil).Post(server.URL, WithBody(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "surf" || atomic.LoadInt32(&body.closed) == 0 {
+		t.Fatalf("body expect sent and closed output %s closed %d.", resp.Text(), body.closed)
+	}
+
+	interceptorErr := errors.New("rejected")
+	body = &closeCounter{Reader: strings.NewReader("surf")}
+	_, err = New(nil).Post(server.URL, WithBody(body), WithRequestInterceptor(func(config *RequestConfig) error {
+		return interceptorErr
+	}))
+	if !errors.Is(err, interceptorErr) || atomic.LoadInt32(&body.closed) != 1 {
+		t.Fatalf("body expect closed on error output %v closed %d.", err, body.closed)
+	}
+}
+
+func TestSurf_DefaultAccept(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Accept")))
+	}))
+	defer server.Close()
+
+	type envelope struct{}
+
+	data := []struct {
+		name   string
+		args   []WithRequestConfig
+		expect string
+	}{
+		{name: "json", args: []WithRequestConfig{WithBody(JSON(map[string]string{}))}, expect: defaultAccept},
+		{name: "xml", args: []WithRequestConfig{WithBody(XML(envelope{}))}, expect: defaultXmlAccept},
+		{name: "soap", args: []WithRequestConfig{WithBody("<Envelope/>"), WithSetHeader(http.Header{"Content-Type": {"text/xml"}})}, expect: defaultXmlAccept},
+		{name: "explicit", args: []WithRequestConfig{WithBody(XML(envelope{})), WithSetHeader(http.Header{"Accept": {"*/*"}})}, expect: "*/*"},
+	}
+	for _, item := range data {
+		resp, err := New(nil).Post(server.URL, item.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != item.expect {
+			t.Fatalf("%s: accept expect %s output %s.", item.name, item.expect, resp.Text())
+		}
+	}
+}
+
+func TestConfig_AutoReferer(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/home", http.StatusFound)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Referer()))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := New(&Config{AutoReferer: true})
+	data := []struct {
+		path   string
+		args   []WithRequestConfig
+		expect string
+	}{
+		// The redirect is followed by http.Client, which sets the Referer itself
+		{path: "/login", expect: server.URL + "/login"},
+		{path: "/list#top", expect: server.URL + "/home"},
+		{path: "/item", args: []WithRequestConfig{WithReferer("https://example.com/")}, expect: "https://example.com/"},
+		{path: "/next", expect: server.URL + "/item"},
+	}
+	for _, item := range data {
+		resp, err := client.Get(server.URL+item.path, item.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != item.expect {
+			t.Fatalf("%s: referer expect %q output %q.", item.path, item.expect, resp.Text())
+		}
+	}
+}
+
+func TestWithAutoReferer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Referer()))
+	}))
+	defer server.Close()
+
+	client := New(&Config{Client: &http.Client{}}).SetDefaultOptions(WithAutoReferer())
+	for i, expect := range []string{"", server.URL + "/0"} {
+		resp, err := client.Get(server.URL + "/" + strconv.Itoa(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != expect {
+			t.Fatalf("referer expect %q output %q.", expect, resp.Text())
+		}
+	}
+
+	// Concurrent requests send the URL of a response completed before them, never a partial one
+	urls := map[string]bool{server.URL + "/0": true, server.URL + "/1": true}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 2; i < 10; i++ {
+		u := server.URL + "/" + strconv.Itoa(i)
+		mu.Lock()
+		urls[u] = true
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(u)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if !urls[resp.Text()] || resp.Text() == u {
+				t.Errorf("concurrent referer unexpected %q for %s.", resp.Text(), u)
+			}
+		}()
+	}
+	wg.Wait()
+
+	resp, err := New(&Config{Client: &http.Client{}}).Get(server.URL, WithReferer("https://example.com/"), WithAutoReferer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "https://example.com/" {
+		t.Fatalf("request referer expect kept output %q.", resp.Text())
+	}
+}
+
+func TestSurf_Redirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/b", http.StatusFound)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/c", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL + "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redirects := resp.Redirects()
+	if len(redirects) != 2 {
+		t.Fatalf("redirects expect 2 output %d.", len(redirects))
+	}
+	if redirects[0].URL != server.URL+"/a" || redirects[0].StatusCode != http.StatusFound {
+		t.Fatalf("first redirect unexpected %+v.", redirects[0])
+	}
+	if redirects[1].URL != server.URL+"/b" || redirects[1].StatusCode != http.StatusMovedPermanently {
+		t.Fatalf("second redirect unexpected %+v.", redirects[1])
+	}
+	if resp.FinalURL() != server.URL+"/c" {
+		t.Fatalf("final url expect %s output %s.", server.URL+"/c", resp.FinalURL())
+	}
+}
+
+func TestSurf_MaxConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := New(&Config{MaxConcurrentRequests: 2, Client: &http.Client{}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Get(server.URL); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Fatalf("concurrency expect at most 2 output %d.", maxInFlight)
+	}
+}
+
+func TestWithJSONBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentType, r.Header.Get(headerContentType))
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Post(server.URL, WithJSONBody(map[string]int{"a": 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != `{"a":1}` || resp.Headers().Get(headerContentType) != defaultJsonContentType {
+		t.Fatalf("json body unexpected %s %s.", resp.Text(), resp.Headers().Get(headerContentType))
+	}
+
+	_, err = New(nil).Post(server.URL, WithJSONBody(make(chan int)))
+	var jsonErr *json.UnsupportedTypeError
+	if !errors.As(err, &jsonErr) {
+		t.Fatalf("expect marshal error output %v.", err)
+	}
+
+	marshal := func(v interface{}) ([]byte, error) {
+		return []byte(`{"custom":true}`), nil
+	}
+	resp, err = New(&Config{JSONMarshal: marshal}).Post(server.URL, WithJSONBody(map[string]int{"a": 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != `{"custom":true}` {
+		t.Fatalf("json body expect Config.JSONMarshal output %s.", resp.Text())
+	}
+
+	body := map[string]int{"a": 1}
+	resp, err = New(nil).Post(server.URL, WithJSONBody(body), WithRequestInterceptor(func(c *RequestConfig) error {
+		body["a"] = 2
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != `{"a":2}` {
+		t.Fatalf("json body expect edited by interceptor output %s.", resp.Text())
+	}
+}
+
+func TestWithResult(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentType, defaultJsonContentType)
+		if r.URL.Path == "/page" {
+			w.Header().Set(headerContentType, "text/html")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("<h1>Bad Gateway</h1>"))
+			return
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message":"bad"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"surf"}`))
+	}))
+	defer server.Close()
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	var errResult struct {
+		Message string `json:"message"`
+	}
+
+	_, err := New(nil).Get(server.URL, WithResult(&result), WithError(&errResult))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "surf" || errResult.Message != "" {
+		t.Fatalf("result unexpected %+v %+v.", result, errResult)
+	}
+
+	_, err = New(nil).Get(server.URL+"/fail", WithResult(&result), WithError(&errResult))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errResult.Message != "bad" {
+		t.Fatalf("error result expect bad output %s.", errResult.Message)
+	}
+
+	resp, err := New(nil).Get(server.URL+"/page", WithResult(&result), WithError(&errResult))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != http.StatusBadGateway || resp.Text() != "<h1>Bad Gateway</h1>" {
+		t.Fatalf("error page expect returned output %d %s.", resp.Status(), resp.Text())
+	}
+}
+
+func TestWithLocalAddr(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.RemoteAddr))
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL, WithLocalAddr(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(resp.Text(), "127.0.0.1:") {
+		t.Fatalf("remote addr expect 127.0.0.1 output %s.", resp.Text())
+	}
+}
+
+func TestWithHostResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	resp, err := New(nil).Get("http://surf.test:"+port, WithHostResolver(map[string]string{"surf.test": "127.0.0.1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "surf.test:"+port {
+		t.Fatalf("host expect surf.test output %s.", resp.Text())
+	}
+}
+
+func TestWithResponseValidator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	errMissing := errors.New("missing signature")
+	_, err := New(nil).Get(server.URL, WithResponseValidator(func(resp *Response) error {
+		if resp.Headers().Get("X-Signature") == "" {
+			return errMissing
+		}
+		return nil
+	}))
+	if !errors.Is(err, errMissing) {
+		t.Fatalf("expect validator error output %v.", err)
+	}
+}
+
+func TestWithResultValidator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":""}`))
+	}))
+	defer server.Close()
+
+	type user struct {
+		Name string `json:"name"`
+	}
+	errRequired := errors.New("name is required")
+	_, err := New(nil).Get(server.URL, WithResult(&user{}), WithResultValidator(func(v interface{}) error {
+		if v.(*user).Name == "" {
+			return errRequired
+		}
+		return nil
+	}))
+	if !errors.Is(err, errRequired) {
+		t.Fatalf("expect validation error output %v.", err)
+	}
+}
+
+func TestWithExpectJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/json" {
+			w.WriteHeader(http.StatusNotAcceptable)
+		}
+		if r.URL.Path == "/html" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte("<html>error</html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL, WithExpectJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Ok() {
+		t.Fatalf("accept expect application/json output status %d.", resp.Status())
+	}
+
+	_, err = New(nil).Get(server.URL+"/html", WithExpectJSON())
+	if !errors.Is(err, ErrUnexpectedContentType) || !strings.Contains(err.Error(), "text/html") {
+		t.Fatalf("expect ErrUnexpectedContentType output %v.", err)
+	}
+}
+
+func TestWithBodyReadTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		if r.URL.Path == "/fast" {
+			return
+		}
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	_, err := New(nil).Get(server.URL, WithBodyReadTimeout(50*time.Millisecond))
+	if !errors.Is(err, ErrBodyReadTimeout) {
+		t.Fatalf("expect ErrBodyReadTimeout output %v.", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expect the read to abort early output %s.", elapsed)
+	}
+
+	resp, err := New(nil).Get(server.URL+"/fast", WithBodyReadTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "partial" {
+		t.Fatalf("body expect partial output %s.", resp.Text())
+	}
+}
+
+func TestSurf_InterceptorBodyMutation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	type payload struct {
+		Name string `json:"name"`
+	}
+	body := &payload{Name: "old"}
+	resp, err := New(nil).Post(server.URL, WithBody(body), WithSetHeader(http.Header{"Content-Type": {"application/json"}}),
+		WithRequestInterceptor(func(config *RequestConfig) error {
+			config.Body.(*payload).Name = "new"
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != `{"name":"new"}` {
+		t.Fatalf("body expect {\"name\":\"new\"} output %s.", resp.Text())
+	}
+
+	form := url.Values{"name": {"old"}}
+	resp, err = New(nil).Post(server.URL, WithBody(form), WithRequestInterceptor(func(config *RequestConfig) error {
+		form.Set("name", "new")
+		config.MarkBodyDirty()
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "name=new" {
+		t.Fatalf("body expect name=new output %s.", resp.Text())
+	}
+}
+
+func TestRequestConfig_SentBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	resp, err := New(nil).Post(server.URL, WithJSONBody(map[string]string{"name": "surf"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := resp.Config().SentBody(); string(body) != `{"name":"surf"}` {
+		t.Fatalf("sent body expect {\"name\":\"surf\"} output %s.", body)
+	}
+
+	resp, err = New(nil).Post(server.URL, WithBody(BodyWriter(func(w io.Writer) error {
+		_, err := w.Write([]byte("stream"))
+		return err
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := resp.Config().SentBody(); body != nil {
+		t.Fatalf("sent body expect nil output %s.", body)
+	}
+}
+
+func TestWithDeadlineHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Request-Timeout")))
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL, WithTimeoutContext(context.Background(), 2*time.Second), WithDeadlineHeader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms, _ := strconv.Atoi(resp.Text()); ms <= 1000 || ms > 2000 {
+		t.Fatalf("remaining timeout expect about 2000ms output %s.", resp.Text())
+	}
+
+	resp, err = New(nil).Get(server.URL, WithDeadlineHeader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "" {
+		t.Fatalf("expect no header without a deadline output %s.", resp.Text())
+	}
+}
+
+type recordLogger struct {
+	lines []string
+}
+
+func (l *recordLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestWithSlowRequestLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	logger := &recordLogger{}
+	client := New(&Config{Logger: logger})
+
+	if _, err := client.Get(server.URL+"/slow", WithSlowRequestLog(10*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(server.URL+"/fast", WithSlowRequestLog(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.lines) != 1 || !strings.Contains(logger.lines[0], "GET "+server.URL+"/slow") {
+		t.Fatalf("expect one slow request log output %q.", logger.lines)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	configLogger, ctxLogger, requestLogger := &recordLogger{}, &recordLogger{}, &recordLogger{}
+	client := &Surf{Config: &Config{Logger: configLogger}, Debug: true}
+	ctx := ContextWithLogger(context.Background(), ctxLogger)
+
+	var intercepted Logger
+	if _, err := client.Get(server.URL, WithContext(ctx), WithRequestInterceptor(func(config *RequestConfig) error {
+		intercepted = config.Logger
+		return nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if len(ctxLogger.lines) == 0 || len(configLogger.lines) != 0 || intercepted != ctxLogger {
+		t.Fatalf("debug output expect the context logger output %q.", ctxLogger.lines)
+	}
+
+	if _, err := client.Get(server.URL, WithContext(ctx), WithLogger(requestLogger)); err != nil {
+		t.Fatal(err)
+	}
+	if len(requestLogger.lines) == 0 || len(configLogger.lines) != 0 {
+		t.Fatalf("debug output expect the request logger output %q.", requestLogger.lines)
+	}
+}
+
+func TestWithRequestModifierRun(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/end", http.StatusFound)
+			return
+		}
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(r.Header.Get("X-Signed")))
+	}))
+	defer server.Close()
+
+	once, always := 0, 0
+	resp, err := New(nil).Get(server.URL+"/start",
+		WithRetry(1),
+		WithRetryBackoff(func(retry int) time.Duration { return 0 }),
+		WithRequestModifier(func(req *http.Request) { once++ }),
+		WithRequestModifier(func(req *http.Request) {
+			always++
+			req.Header.Set("X-Signed", req.URL.Path)
+		}, RunAlways),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two attempts, each redirected once
+	if once != 1 || always != 4 {
+		t.Fatalf("modifier runs expect 1 and 4 output %d and %d.", once, always)
+	}
+	if resp.Text() != "/end" {
+		t.Fatalf("signed path expect /end output %s.", resp.Text())
+	}
+}
+
+func TestSurf_UnresolvedPathParam(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	_, err := New(nil).Get(server.URL+"/users/:id/posts/:postId", WithSetParam("id", "1"))
+	if !errors.Is(err, ErrUnresolvedPathParam) || !strings.Contains(err.Error(), ":postId") {
+		t.Fatalf("expect ErrUnresolvedPathParam output %v.", err)
+	}
+
+	resp, err := New(nil).Get(server.URL+"/v1/items:batchGet/:id", WithSetParam("id", "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "/v1/items:batchGet/1" {
+		t.Fatalf("path expect /v1/items:batchGet/1 output %s.", resp.Text())
+	}
+
+	resp, err = New(nil).Get(server.URL+"/files/:raw", WithAllowUnresolvedParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "/files/:raw" {
+		t.Fatalf("path expect /files/:raw output %s.", resp.Text())
+	}
+}
+
+// newContinueServer serves "/continue" with a 100 Continue sent after 30ms and "/silent" without one,
+// responding with the request body and the time it took to arrive.
+func newContinueServer(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				req, err := http.ReadRequest(reader)
+				if err != nil {
+					return
+				}
+				start := time.Now()
+				if req.URL.Path == "/continue" {
+					time.Sleep(30 * time.Millisecond)
+					_, _ = conn.Write([]byte("HTTP/1.1 100 Continue\r\n\r\n"))
+				}
+				body, _ := io.ReadAll(req.Body)
+				text := fmt.Sprintf("%s %s %d", req.Header.Get("Expect"), body, time.Since(start).Milliseconds())
+				_, _ = fmt.Fprintf(conn, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s", len(text), text)
+			}()
+		}
+	}()
+	return "http://" + ln.Addr().String()
+}
+
+func TestWithExpect100Timeout(t *testing.T) {
+	serverURL := newContinueServer(t)
+
+	resp, err := New(nil).Post(serverURL+"/continue", WithBody("surf"), WithExpect100Timeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(resp.Text(), "100-continue surf") {
+		t.Fatalf("body expect 100-continue surf output %s.", resp.Text())
+	}
+	if resp.Performance.ContinueWait < 30*time.Millisecond {
+		t.Fatalf("continue wait expect at least 30ms output %s.", resp.Performance.ContinueWait)
+	}
+
+	resp, err = New(nil).Post(serverURL+"/silent", WithBody("surf"), WithExpect100Timeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var waited int
+	if _, err := fmt.Sscanf(resp.Text(), "100-continue surf %d", &waited); err != nil || waited < 40 {
+		t.Fatalf("expect the body after the timeout output %s.", resp.Text())
+	}
+	if resp.Performance.ContinueWait != 0 {
+		t.Fatalf("continue wait expect 0 output %s.", resp.Performance.ContinueWait)
+	}
+}
+
+func TestConfig_AcceptLanguage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer server.Close()
+
+	client := New(&Config{AcceptLanguage: []string{"en-US", "en"}})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "en-US,en;q=0.9" {
+		t.Fatalf("accept language expect en-US,en;q=0.9 output %s.", resp.Text())
+	}
+
+	resp, err = client.Get(server.URL, WithAcceptLanguage("fr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "fr" {
+		t.Fatalf("accept language expect fr output %s.", resp.Text())
+	}
+}
+
+func TestWithProxyFunc(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("proxied " + r.URL.String()))
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	var selected int32
+	resp, err := New(nil).Get("http://surf.invalid/path", WithProxyFunc(func(r *http.Request) (*url.URL, error) {
+		atomic.AddInt32(&selected, 1)
+		return proxyURL, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "proxied http://surf.invalid/path" || atomic.LoadInt32(&selected) != 1 {
+		t.Fatalf("expect a proxied request output %s.", resp.Text())
+	}
+}
+
+func TestWithConnectionClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			if !r.Close {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		if r.Close {
+			_, _ = w.Write([]byte("close"))
+		}
+	}))
+	defer server.Close()
+
+	client := New(&Config{Client: &http.Client{}})
+
+	resp, err := client.Get(server.URL, WithConnectionClose())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "close" {
+		t.Fatal("expect connection close request")
+	}
+
+	// Every redirect hop closes its connection too
+	resp, err = client.Get(server.URL+"/redirect", WithConnectionClose())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status() != http.StatusOK || resp.Text() != "close" {
+		t.Fatalf("redirect expect connection close output %d %s.", resp.Status(), resp.Text())
+	}
+
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "" {
+		t.Fatal("expect keep-alive request")
+	}
+}
+
+func TestWithTrailer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		// Trailers are only available once the body has been read
+		_, _ = fmt.Fprintf(w, "%s|%s|%s", strings.Join(r.TransferEncoding, ","), r.Trailer.Get("Grpc-Status"), body)
+	}))
+	defer server.Close()
+
+	trailer := http.Header{"Grpc-Status": {"0"}}
+	client := New(nil)
+
+	resp, err := client.Post(server.URL, WithBody("surf"), WithTrailer(trailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "chunked|0|surf" {
+		t.Fatalf("trailer expect chunked|0|surf output %s.", resp.Text())
+	}
+
+	resp, err = client.Post(server.URL, WithTrailer(trailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "chunked|0|" {
+		t.Fatalf("trailer without body expect chunked|0| output %s.", resp.Text())
+	}
+}
+
+func TestSurf_SetDefaultOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s|%s", r.Header.Get("X-Client"), r.URL.Query().Get("v"))
+	}))
+	defer server.Close()
+
+	client := New(nil).SetDefaultOptions(
+		WithHeader(http.Header{"X-Client": {"surf"}}),
+		WithQuery(url.Values{"v": {"1"}}),
+	)
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "surf|1" {
+		t.Fatalf("default options expect surf|1 output %s.", resp.Text())
+	}
+
+	resp, err = client.Get(server.URL, WithQuery(url.Values{"v": {"2"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "surf|2" {
+		t.Fatalf("request options expect override surf|2 output %s.", resp.Text())
+	}
+
+	resp, err = client.Request(&RequestConfig{Url: server.URL, Header: http.Header{"X-Request": {"1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "surf|1" || resp.config.Header.Get("X-Request") != "1" {
+		t.Fatalf("request config expect defaults filled output %s %v.", resp.Text(), resp.config.Header)
+	}
+
+	var validated []string
+	validator := func(name string) WithRequestConfig {
+		return WithResponseValidator(func(resp *Response) error {
+			validated = append(validated, name)
+			return nil
+		})
+	}
+	defaultHeader := http.Header{"X-Client": {"surf"}}
+	client.SetDefaultOptions(WithHeader(defaultHeader), validator("default"))
+	if _, err = client.Post(server.URL, WithJSONBody(map[string]int{"a": 1}), validator("request")); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(validated, ",") != "default,request" {
+		t.Fatalf("validators expect default,request output %v.", validated)
+	}
+	if len(defaultHeader) != 1 {
+		t.Fatalf("default header expect unchanged output %v.", defaultHeader)
+	}
+
+	resp, err = client.SetDefaultOptions().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "|" {
+		t.Fatalf("cleared default options expect | output %s.", resp.Text())
+	}
+}
+
+func TestSurf_SetDefaultOptions_ReuseConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Client") + "|" + r.Header.Get("X-Request")))
+	}))
+	defer server.Close()
+
+	var calls int
+	client := New(nil).SetDefaultOptions(
+		WithHeader(http.Header{"X-Client": {"surf"}}),
+		WithRequestInterceptor(func(config *RequestConfig) error {
+			calls++
+			return nil
+		}),
+	)
+
+	config := &RequestConfig{Url: server.URL, Header: http.Header{"X-Request": {"1"}}}
+	for i := 1; i <= 3; i++ {
+		resp, err := client.Request(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != "surf|1" {
+			t.Fatalf("request %d expect surf|1 output %s.", i, resp.Text())
+		}
+		if calls != i {
+			t.Fatalf("default interceptor expect %d calls output %d.", i, calls)
+		}
+	}
+	if len(config.RequestInterceptors) != 0 || len(config.Header) != 1 {
+		t.Fatalf("request config expect unchanged output %v %v.", len(config.RequestInterceptors), config.Header)
+	}
+}
+
+func TestWithAcceptEncoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get(headerAcceptEncoding)))
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != acceptEncoding() {
+		t.Fatalf("accept encoding expect %s output %s.", acceptEncoding(), resp.Text())
+	}
+
+	resp, err = New(nil).Get(server.URL, WithAcceptEncoding("identity"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "identity" {
+		t.Fatalf("accept encoding expect identity output %s.", resp.Text())
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s|%s", r.Header.Get(headerRequestID), r.Header.Get("X-Trace-Id"))
+	}))
+	defer server.Close()
+
+	resp, err := New(nil).Get(server.URL, WithRequestID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID() == "" || resp.Text() != resp.RequestID()+"|" {
+		t.Fatalf("generated request id expect sent output %s %s.", resp.RequestID(), resp.Text())
+	}
+
+	resp, err = New(nil).Get(server.URL, WithRequestIDHeader("x-trace-id"), WithSetHeader(http.Header{"X-Trace-Id": {"trace-1"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID() != "trace-1" || resp.Text() != "|trace-1" {
+		t.Fatalf("caller request id expect kept output %s %s.", resp.RequestID(), resp.Text())
+	}
+
+	resp, err = New(nil).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID() != "" || resp.Text() != "|" {
+		t.Fatalf("request id expect disabled output %s %s.", resp.RequestID(), resp.Text())
+	}
+}
+
+func TestWithTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Transport")))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	s := New(&Config{Client: client})
+
+	resp, err := s.Get(server.URL, WithTransport(&headerTransport{value: "request"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "request" {
+		t.Fatalf("transport expect request output %s.", resp.Text())
+	}
+	if resp.config.Client == client || resp.config.Client.Timeout != client.Timeout {
+		t.Fatal("transport expect cloned client keeping its settings.")
+	}
+	if client.Transport != nil {
+		t.Fatal("transport expect shared client unchanged.")
+	}
+
+	resp, err = s.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "" {
+		t.Fatalf("shared client expect default transport output %s.", resp.Text())
+	}
+}
+
+func TestWithStream_ValidatorError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentType, "text/html")
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	client := New(&Config{MaxConcurrentRequests: 1, MaxConcurrentRequestsPerHost: 1, Client: &http.Client{}})
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := client.Get(server.URL, WithStream(), WithExpectJSON(), WithContext(ctx))
+		cancel()
+		if errors.Is(err, ErrTimeout) {
+			t.Fatal("failed streaming request expect limiter slot freed.")
+		}
+		if err == nil {
+			t.Fatal("validator expect error.")
+		}
+	}
+	if n := len(client.hostLimiter.hosts); n != 0 {
+		t.Fatalf("host semaphore expect released output %d.", n)
+	}
+}
+
+func TestWithMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var requestOperation, responseOperation interface{}
+	var performance *Performance
+	client := New(&Config{
+		Client: &http.Client{},
+		RequestInterceptors: RequestInterceptorChain{
+			func(c *RequestConfig) error {
+				requestOperation = c.Metadata["operation"]
+				return nil
+			},
+		},
+		// Metrics read the Performance of the response labelled with the metadata
+		ResponseInterceptors: ResponseInterceptorChain{
+			func(resp *Response) error {
+				responseOperation = resp.Config().Metadata["operation"]
+				performance = resp.Performance
+				return nil
+			},
+		},
+	})
+
+	resp, err := client.Get(server.URL, WithMetadata("operation", "list-users"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requestOperation != "list-users" || responseOperation != "list-users" || performance == nil {
+		t.Fatalf("interceptors expect metadata output %v %v.", requestOperation, responseOperation)
+	}
+	if resp.Config().Metadata["operation"] != "list-users" {
+		t.Fatalf("response config expect metadata output %v.", resp.Config().Metadata)
+	}
+}
@@ -16,8 +16,13 @@ type clientTrace struct {
 	tlsHandshakeDone     time.Time
 	gotConn              time.Time
 	gotFirstResponseByte time.Time
+	wait100Continue      time.Time
+	got100Continue       time.Time
 	endTime              time.Time
 	gotConnInfo          httptrace.GotConnInfo
+
+	// onGotConn is called for every connection obtained, see Surf.Stats.
+	onGotConn func(ci httptrace.GotConnInfo)
 }
 
 func (t *clientTrace) createContext(ctx context.Context) context.Context {
@@ -47,10 +52,19 @@ func (t *clientTrace) createContext(ctx context.Context) context.Context {
 			GotConn: func(ci httptrace.GotConnInfo) {
 				t.gotConn = time.Now()
 				t.gotConnInfo = ci
+				if t.onGotConn != nil {
+					t.onGotConn(ci)
+				}
 			},
 			GotFirstResponseByte: func() {
 				t.gotFirstResponseByte = time.Now()
 			},
+			Wait100Continue: func() {
+				t.wait100Continue = time.Now()
+			},
+			Got100Continue: func() {
+				t.got100Continue = time.Now()
+			},
 			TLSHandshakeStart: func() {
 				t.tlsHandshakeStart = time.Now()
 			},
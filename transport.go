@@ -0,0 +1,113 @@
+package surf
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// ownedTransport returns the transport Surf wires into the client from the Config transport settings,
+// or nil when the client transport is used as is. It is built once from Transport or Client so
+// connections are pooled across requests.
+func (c *Config) ownedTransport() http.RoundTripper {
+	if c.MaxConnsPerHost <= 0 && c.IdleConnTimeout == 0 && c.KeepAlive == 0 {
+		return c.Transport
+	}
+
+	c.transportOnce.Do(func() {
+		c.transport = c.Transport
+
+		base := c.Transport
+		if base == nil && c.Client != nil {
+			base = c.Client.Transport
+		}
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		t, ok := base.(*http.Transport)
+		if !ok {
+			return
+		}
+		t = t.Clone()
+		if c.MaxConnsPerHost > 0 {
+			t.MaxConnsPerHost = c.MaxConnsPerHost
+		}
+		if c.IdleConnTimeout != 0 {
+			t.IdleConnTimeout = c.IdleConnTimeout
+		}
+		if c.KeepAlive != 0 {
+			dialer := newDialer()
+			dialer.KeepAlive = c.KeepAlive
+			t.DialContext = dialer.DialContext
+		}
+		c.transport = t
+	})
+	return c.transport
+}
+
+// transportFor returns the transport selected by TransportFor for host, cached per host.
+func (c *Config) transportFor(host string) http.RoundTripper {
+	c.transportsMu.Lock()
+	defer c.transportsMu.Unlock()
+
+	if rt, ok := c.transports[host]; ok {
+		return rt
+	}
+	rt := c.TransportFor(host)
+	if c.transports == nil {
+		c.transports = make(map[string]http.RoundTripper)
+	}
+	c.transports[host] = rt
+	return rt
+}
+
+// applyTransportModifiers clones the request transport and applies the transport modifiers to it.
+// The modifiers are skipped when the transport is not an *http.Transport.
+func (rc *RequestConfig) applyTransportModifiers() {
+	base := rc.Transport
+	if base == nil {
+		base = rc.Client.Transport
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t, ok := base.(*http.Transport)
+	if !ok {
+		return
+	}
+
+	t = t.Clone()
+	for _, fn := range rc.transportModifiers {
+		fn(t)
+	}
+	rc.Transport = t
+	rc.requestTransport = t
+}
+
+// addTransportModifier registers a modifier applied to a per-request clone of the transport.
+func (rc *RequestConfig) addTransportModifier(fn func(t *http.Transport)) {
+	rc.transportModifiers = append(rc.transportModifiers, fn)
+}
+
+// newDialer returns a dialer with the same settings as the one of http.DefaultTransport.
+func newDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+}
+
+// modifierTransport runs the attempt modifiers on a copy of every request it sends.
+type modifierTransport struct {
+	base      http.RoundTripper
+	modifiers []RequestModifier
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *modifierTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for _, fn := range t.modifiers {
+		fn(req)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -1,69 +1,362 @@
 package surf
 
 import (
-	"compress/flate"
+	"bytes"
 	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
-
-	"github.com/dsnet/compress/brotli"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-func readBody(res *http.Response, maxBodyLength int) ([]byte, error) {
-	defer res.Body.Close()
+// countingReader counts the bytes read from the wire and records the last read error.
+type countingReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+// Read implements io.Reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if err != nil {
+		c.err = err
+	}
+	return n, err
+}
+
+// timeoutBody closes the response body when it is not read completely within the timeout,
+// failing the pending and later reads with ErrBodyReadTimeout.
+type timeoutBody struct {
+	io.ReadCloser
+	timer    *time.Timer
+	timedOut atomic.Bool
+}
+
+func newTimeoutBody(body io.ReadCloser, timeout time.Duration) *timeoutBody {
+	b := &timeoutBody{ReadCloser: body}
+	b.timer = time.AfterFunc(timeout, func() {
+		b.timedOut.Store(true)
+		b.ReadCloser.Close()
+	})
+	return b
+}
+
+// Read implements io.Reader.
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.timer.Stop()
+	} else if err != nil && b.timedOut.Load() {
+		err = ErrBodyReadTimeout
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (b *timeoutBody) Close() error {
+	b.timer.Stop()
+	return b.ReadCloser.Close()
+}
+
+// decodedBody is a response body decoded according to its Content-Encoding.
+// Read errors are reported as *BodyError. Closing it closes the decoders and the underlying body.
+type decodedBody struct {
+	io.Reader
+	closers  []io.Closer
+	wire     *countingReader
+	encoding string
+	decoded  bool
+}
+
+// Read implements io.Reader.
+func (b *decodedBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		err = b.bodyError(err)
+	}
+	return n, err
+}
 
-	var reader io.Reader = res.Body
+// bodyError classifies err as a decompression error, unless reading from the wire failed.
+func (b *decodedBody) bodyError(err error) error {
+	var bodyErr *BodyError
+	if errors.As(err, &bodyErr) {
+		return err
+	}
+
+	kind := ErrBodyRead
+	if b.decoded && (b.wire.err == nil || b.wire.err == io.EOF) {
+		kind = ErrDecompress
+	}
+	return &BodyError{Kind: kind, Encoding: b.encoding, BytesRead: b.wire.n, Err: err}
+}
+
+// Close implements io.Closer.
+func (b *decodedBody) Close() error {
+	var err error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		if cerr := b.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	b.closers = nil
+	return err
+}
+
+// decodeBody wraps the response body with the decoders of its Content-Encoding.
+// The response body is closed when an error is returned.
+func decodeBody(res *http.Response) (io.ReadCloser, error) {
+	wire := &countingReader{r: res.Body}
 
 	// Check for Content-Encoding and decode accordingly
 	// https://developer.mozilla.org/zh-CN/docs/Web/HTTP/Headers/Content-Encoding
-	encoding := res.Header.Get(headerContentEncoding)
+	encoding := strings.ToLower(strings.TrimSpace(res.Header.Get(headerContentEncoding)))
+
+	body := &decodedBody{Reader: wire, closers: []io.Closer{res.Body}, wire: wire, encoding: encoding}
+
 	// If no content, but headers still say that it is encoded,
 	if res.StatusCode != http.StatusNoContent || res.Request.Method != http.MethodHead {
-		var err error
-		switch encoding {
-		case "gzip", "x-gzip", "compress", "x-compress":
-			reader, err = gzip.NewReader(res.Body)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create Gzip reader: %w", err)
+		// Multiple encodings are listed in the order they were applied, decode in reverse
+		ctx := context.Background()
+		if res.Request != nil {
+			ctx = res.Request.Context()
+		}
+		codings := strings.Split(encoding, ",")
+		for i := len(codings) - 1; i >= 0; i-- {
+			coding := strings.TrimSpace(codings[i])
+			decoder, ok := lookupContextDecoder(ctx, coding)
+			if !ok {
+				continue
 			}
-			defer reader.(*gzip.Reader).Close()
-		case "br":
-			reader, err = brotli.NewReader(res.Body, nil)
+			body.decoded = true
+			decoded, err := decoder(body.Reader)
 			if err != nil {
-				return nil, fmt.Errorf("failed to create Brotli reader: %w", err)
+				body.Close()
+				return nil, fmt.Errorf("failed to create %s reader: %w", coding, body.bodyError(err))
+			}
+			if closer, ok := decoded.(io.Closer); ok {
+				body.closers = append(body.closers, closer)
 			}
-			defer reader.(*brotli.Reader).Close()
-		case "deflate":
-			reader = flate.NewReader(res.Body)
-			defer reader.(io.ReadCloser).Close()
+			body.Reader = decoded
 		}
 	}
 
-	size := 0
+	return body, nil
+}
+
+// checkContentEncoding returns an error wrapping ErrUnsupportedContentEncoding when the response body
+// uses a Content-Encoding without registered decoder.
+func checkContentEncoding(res *http.Response) error {
+	if res.StatusCode == http.StatusNoContent || res.Request.Method == http.MethodHead {
+		return nil
+	}
+	for _, coding := range strings.Split(res.Header.Get(headerContentEncoding), ",") {
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" || coding == "identity" {
+			continue
+		}
+		if _, ok := lookupContextDecoder(res.Request.Context(), coding); !ok {
+			return fmt.Errorf("%w: %q", ErrUnsupportedContentEncoding, coding)
+		}
+	}
+	return nil
+}
+
+// readBody reads the decoded response body. bufferSize is the initial buffer capacity
+// used when the response has no Content-Length, defaultReadBufferSize if not positive.
+func readBody(res *http.Response, maxBodyLength, bufferSize int) ([]byte, error) {
+	reader, err := decodeBody(res)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	size := bufferSize
+	if size <= 0 {
+		size = defaultReadBufferSize
+	}
+	n, ok, err := bodyContentLength(res, maxBodyLength)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		// Read errors are already reported as *BodyError by the decoded body
+		return readAllInitCap(reader, n)
+	}
+
+	return readAllPooled(reader, size)
+}
+
+// readRawBody reads the undecoded response body and replaces it with a reader over the data read.
+func readRawBody(res *http.Response) ([]byte, error) {
+	wire := &countingReader{r: res.Body}
+	raw, err := io.ReadAll(wire)
+	res.Body.Close()
+	if err != nil {
+		return nil, &BodyError{Kind: ErrBodyRead, Encoding: res.Header.Get(headerContentEncoding), BytesRead: wire.n, Err: err}
+	}
+	res.Body = io.NopCloser(bytes.NewReader(raw))
+	return raw, nil
+}
+
+// readBodyBuffer reads the decoded response body into buf, which holds the data afterwards.
+func readBodyBuffer(res *http.Response, maxBodyLength int, buf *bytes.Buffer) error {
+	reader, err := decodeBody(res)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	n, ok, err := bodyContentLength(res, maxBodyLength)
+	if err != nil {
+		return err
+	}
+	if ok {
+		buf.Grow(n)
+	}
+	_, err = buf.ReadFrom(reader)
+	return err
+}
+
+// bodyContentLength returns the Content-Length of the response, ok is false when it is unknown.
+// An error is returned when it exceeds maxBodyLength.
+func bodyContentLength(res *http.Response, maxBodyLength int) (n int, ok bool, err error) {
 	contentLength := res.Header.Get(headerContentLength)
-	if contentLength != "" {
-		size, _ = strconv.Atoi(contentLength)
+	if contentLength == "" {
+		return 0, false, nil
+	}
+	n, err = strconv.Atoi(contentLength)
+	if err != nil || n < 0 {
+		return 0, false, nil
+	}
+	if maxBodyLength > 0 && n > maxBodyLength {
+		return 0, false, fmt.Errorf("response body exceeds the maximum length of %d", maxBodyLength)
+	}
+	return n, true, nil
+}
+
+// maxPooledBufferSize caps the buffers kept in readBufferPool so one huge body does not pin memory.
+const maxPooledBufferSize = 1 << 20
+
+var readBufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// readAllPooled reads r into a pooled scratch buffer and returns a copy of the data.
+func readAllPooled(r io.Reader, initCap int) ([]byte, error) {
+	buf := readBufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			buf.Reset()
+			readBufferPool.Put(buf)
+		}
+	}()
+
+	buf.Reset()
+	buf.Grow(initCap)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
 	}
+	return append([]byte(nil), buf.Bytes()...), nil
+}
 
-	if maxBodyLength > 0 && size > maxBodyLength {
-		return nil, fmt.Errorf("response body exceeds the maximum length of %d", maxBodyLength)
+// debugBody returns the request body for the debug output, truncated to limit bytes.
+// It reads a copy from GetBody so the body sent is not consumed, and does not read
+// bodies that cannot be replayed.
+func debugBody(req *http.Request, limit int) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "<empty>"
 	}
+	if req.GetBody == nil {
+		return "<not replayable>"
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return fmt.Sprintf("<%s>", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	if err != nil {
+		return fmt.Sprintf("<%s>", err)
+	}
+	if len(data) > limit {
+		// The total is unknown for a chunked body
+		if req.ContentLength > 0 {
+			return fmt.Sprintf("%s... (truncated to %d of %d bytes)", data[:limit], limit, req.ContentLength)
+		}
+		return fmt.Sprintf("%s... (truncated to %d bytes)", data[:limit], limit)
+	}
+	return string(data)
+}
 
-	data, err := readAllInitCap(reader, size)
+// gzipBytes compresses b with gzip at the given level, gzip.DefaultCompression when zero.
+func gzipBytes(b []byte, level int) ([]byte, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, level)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// acceptLanguage formats language tags as an Accept-Language header, decreasing the q-value
+// of each tag after the first by 0.1 down to 0.1.
+func acceptLanguage(tags []string) string {
+	parts := make([]string, 0, len(tags))
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if i == 0 || strings.Contains(tag, ";") {
+			parts = append(parts, tag)
+			continue
+		}
+		q := 10 - i
+		if q < 1 {
+			q = 1
+		}
+		parts = append(parts, fmt.Sprintf("%s;q=0.%d", tag, q))
+	}
+	return strings.Join(parts, ",")
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
 
-	return data, nil
+// trimBOM strips a leading UTF-8 byte order mark.
+func trimBOM(b []byte) []byte {
+	return bytes.TrimPrefix(b, utf8BOM)
 }
 
+// readAllInitCap reads r into a buffer of initCap capacity, the known body length.
+// An empty body is not read to avoid allocating a buffer for 204 and other empty responses.
 func readAllInitCap(r io.Reader, initCap int) ([]byte, error) {
-	if initCap <= 0 {
-		initCap = 512
+	if initCap == 0 {
+		return []byte{}, nil
+	}
+	if initCap < 0 {
+		initCap = defaultReadBufferSize
 	}
 	b := make([]byte, 0, initCap)
 	for {
@@ -76,7 +369,7 @@ func readAllInitCap(r io.Reader, initCap int) ([]byte, error) {
 			err = nil
 			break
 		} else if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
+			return nil, err
 		}
 	}
 	return b, nil
@@ -117,3 +410,155 @@ func defaultValue[T any](value, defaultValue T) T {
 	}
 	return value
 }
+
+// generateRequestID returns a random UUID v4 formatted string.
+func generateRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf)
+}
+
+// isEncodedBody reports whether body is marshaled by the JSON or XML codec, see RequestConfig.getRequestBody.
+func isEncodedBody(body interface{}) bool {
+	switch body.(type) {
+	case nil, io.Reader, []byte, *multipartFile, url.Values, string, BodyWriter, func(w io.Writer) error:
+		return false
+	}
+	return true
+}
+
+// setRequestBody replaces the body of req. As http.NewRequest does, the length of an in-memory
+// body is set and the body stays replayable with GetBody for retries and redirects.
+func setRequestBody(req *http.Request, body io.Reader) {
+	req.ContentLength = 0
+	req.GetBody = nil
+
+	rc, ok := body.(io.ReadCloser)
+	if !ok && body != nil {
+		rc = io.NopCloser(body)
+	}
+	req.Body = rc
+
+	switch v := body.(type) {
+	case *bytes.Buffer:
+		req.ContentLength = int64(v.Len())
+		buf := v.Bytes()
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(buf)), nil
+		}
+	case *bytes.Reader:
+		req.ContentLength = int64(v.Len())
+		snapshot := *v
+		req.GetBody = func() (io.ReadCloser, error) {
+			r := snapshot
+			return io.NopCloser(&r), nil
+		}
+	case *strings.Reader:
+		req.ContentLength = int64(v.Len())
+		snapshot := *v
+		req.GetBody = func() (io.ReadCloser, error) {
+			r := snapshot
+			return io.NopCloser(&r), nil
+		}
+	}
+	if req.GetBody != nil && req.ContentLength == 0 {
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
+	}
+}
+
+// closeBodies closes the request bodies implementing io.Closer, once each.
+func closeBodies(bodies ...interface{}) {
+	for i, body := range bodies {
+		closer, ok := body.(io.Closer)
+		if !ok {
+			continue
+		}
+		closed := false
+		for _, prev := range bodies[:i] {
+			closed = closed || isSameBody(prev, body)
+		}
+		if !closed {
+			_ = closer.Close()
+		}
+	}
+}
+
+// isSameBody reports whether two request bodies are the same value, without panicking on
+// uncomparable types such as []byte or BodyWriter.
+func isSameBody(a, b interface{}) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	}
+	if ta == nil {
+		return true
+	}
+	if ta.Comparable() {
+		return a == b
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch ta.Kind() {
+	case reflect.Slice:
+		return va.Pointer() == vb.Pointer() && va.Len() == vb.Len()
+	case reflect.Func, reflect.Map:
+		return va.Pointer() == vb.Pointer()
+	}
+	return false
+}
+
+// clientRedirects returns the redirect hops followed by the http.Client before resp, in order.
+func clientRedirects(resp *http.Response) []RedirectInfo {
+	var chain []RedirectInfo
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		chain = append([]RedirectInfo{{
+			URL:        req.Response.Request.URL.String(),
+			StatusCode: req.Response.StatusCode,
+		}}, chain...)
+	}
+	return chain
+}
+
+// jsonTypeName returns the JSON type name of a value decoded into an interface{}.
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	default:
+		return "number"
+	}
+}
+
+// parseAbsoluteURL parses rawURL, requiring a scheme and a host.
+func parseAbsoluteURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrAbsoluteURLRequired, rawURL)
+	}
+	return u, nil
+}
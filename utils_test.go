@@ -1,6 +1,10 @@
 package surf
 
 import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -22,3 +26,120 @@ func TestIsZero(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func newChunkedResponse(body []byte) *http.Response {
+	return &http.Response{
+		Header: make(http.Header),
+		Body:   io.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+func TestReadBodyLargerThanBuffer(t *testing.T) {
+	body := bytes.Repeat([]byte("surf"), 64*1024)
+	data, err := readBody(newChunkedResponse(body), 0, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, body) {
+		t.Fatalf("readBody expect %d bytes output %d.", len(body), len(data))
+	}
+
+	// The returned body must not share memory with the pooled buffer
+	small, err := readBody(newChunkedResponse([]byte("hello")), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBody(newChunkedResponse([]byte("world")), 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if string(small) != "hello" {
+		t.Fatalf("readBody expect hello output %s.", small)
+	}
+}
+
+func TestReadBodyEmpty(t *testing.T) {
+	res := newChunkedResponse(nil)
+	res.Header.Set(headerContentLength, "0")
+	data, err := readBody(res, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0 || cap(data) != 0 {
+		t.Fatalf("empty body expect no buffer output cap %d.", cap(data))
+	}
+}
+
+func BenchmarkReadBody(b *testing.B) {
+	body := bytes.Repeat([]byte("surf"), 8*1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := readBody(newChunkedResponse(body), 0, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		tags   []string
+		expect string
+	}{
+		{[]string{"en-US"}, "en-US"},
+		{[]string{"en-US", "en", "fr"}, "en-US,en;q=0.9,fr;q=0.8"},
+		{[]string{"de", "en;q=0.5"}, "de,en;q=0.5"},
+		{[]string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}, "a,b;q=0.9,c;q=0.8,d;q=0.7,e;q=0.6,f;q=0.5,g;q=0.4,h;q=0.3,i;q=0.2,j;q=0.1,k;q=0.1"},
+	}
+	for _, tt := range tests {
+		if output := acceptLanguage(tt.tags); output != tt.expect {
+			t.Fatalf("accept language expect %s output %s.", tt.expect, output)
+		}
+	}
+}
+
+func TestDebugBody(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("surf body")))
+	if output := debugBody(req, 4); output != "surf... (truncated to 4 of 9 bytes)" {
+		t.Fatalf("debug body expect truncated output %q.", output)
+	}
+	if output := debugBody(req, 1024); output != "surf body" {
+		t.Fatalf("debug body expect full body output %q.", output)
+	}
+	if data, _ := io.ReadAll(req.Body); string(data) != "surf body" {
+		t.Fatalf("body expect not consumed output %q.", data)
+	}
+
+	req.ContentLength = -1
+	if output := debugBody(req, 4); output != "surf... (truncated to 4 bytes)" {
+		t.Fatalf("debug body expect truncated without total output %q.", output)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, "/", io.NopCloser(bytes.NewReader([]byte("surf"))))
+	if output := debugBody(req, 1024); output != "<not replayable>" {
+		t.Fatalf("debug body expect not replayable output %q.", output)
+	}
+}
+
+func TestSetRequestBody(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "/", nil)
+	for _, body := range []io.Reader{bytes.NewBufferString("surf"), bytes.NewReader([]byte("surf")), strings.NewReader("surf")} {
+		setRequestBody(req, body)
+		if req.ContentLength != 4 || req.GetBody == nil {
+			t.Fatalf("%T body expect replayable output %d.", body, req.ContentLength)
+		}
+		_, _ = io.ReadAll(req.Body)
+		replay, _ := req.GetBody()
+		if data, _ := io.ReadAll(replay); string(data) != "surf" {
+			t.Fatalf("%T body expect replayed output %s.", body, data)
+		}
+	}
+
+	setRequestBody(req, strings.NewReader(""))
+	if req.Body != http.NoBody || req.ContentLength != 0 {
+		t.Fatal("empty body expect http.NoBody.")
+	}
+
+	setRequestBody(req, io.MultiReader(strings.NewReader("surf")))
+	if req.GetBody != nil || req.ContentLength != 0 {
+		t.Fatal("streamed body expect unknown length.")
+	}
+}